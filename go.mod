@@ -6,7 +6,6 @@ require (
 	github.com/panjf2000/ants/v2 v2.10.0
 	github.com/shirou/gopsutil/v4 v4.24.11
 	github.com/stretchr/testify v1.10.0
-	github.com/valyala/bytebufferpool v1.0.0
 	golang.org/x/sync v0.10.0
 )
 
@@ -0,0 +1,179 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+)
+
+const (
+	ClusterINI   = "cluster.ini"
+	ClusterToken = "cluster_token.txt"
+	ServerINI    = "server.ini"
+)
+
+// DefaultConfDir returns the default directory which the server stores clusters in, ~/.klei/DoNotStarveTogether
+func DefaultConfDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".klei", "DoNotStarveTogether"), nil
+}
+
+// Cluster represent a cluster directory of the server, which contains cluster.ini and shard directories
+type Cluster struct {
+	// name of cluster directory, which is the -cluster argument of the server
+	Name string
+	Dir  string
+
+	// content of cluster.ini
+	Config *INI
+	// content of cluster_token.txt
+	Token string
+
+	Shards []*Shard
+}
+
+// Shard returns the named shard, or nil if not exist
+func (c *Cluster) Shard(name string) *Shard {
+	for _, shard := range c.Shards {
+		if shard.Name == name {
+			return shard
+		}
+	}
+	return nil
+}
+
+// Shard represent a shard directory of cluster, which contains server.ini
+type Shard struct {
+	// name of shard directory, which is the -shard argument of the server
+	Name string
+	Dir  string
+
+	// content of server.ini
+	Config *INI
+	// content of modoverrides.lua, empty if not exist
+	ModOverrides string
+
+	// pid of the running server process of the shard, 0 if not running or not adopted
+	PID int32
+}
+
+// IsMaster returns whether the shard is master, by is_master in [SHARD] of server.ini
+func (s *Shard) IsMaster() bool {
+	isMaster, _ := s.Config.Get("SHARD", "is_master")
+	return strings.EqualFold(isMaster, "true")
+}
+
+// Discover scans dir for clusters created by the server, other tools or manually,
+// a cluster is a sub directory with cluster.ini, and its shards are the sub directories with server.ini.
+// A malformed cluster does not stop discovering the others, it returns the clusters loaded along with
+// the errors of the malformed ones joined.
+func Discover(dir string) ([]*Cluster, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		clusters []*Cluster
+		errs     []error
+	)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		cluster, err := Load(filepath.Join(dir, entry.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, errors.Join(errs...)
+}
+
+// Load loads the cluster in dir, returns error wraps os.ErrNotExist if dir has no cluster.ini
+func Load(dir string) (*Cluster, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := ReadINI(filepath.Join(dir, ClusterINI))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errcode.Wrap(errcode.ClusterNotFound, err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	cluster := &Cluster{Name: filepath.Base(dir), Dir: dir, Config: config}
+
+	token, err := os.ReadFile(filepath.Join(dir, ClusterToken))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	cluster.Token = strings.TrimSpace(string(token))
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		shardDir := filepath.Join(dir, entry.Name())
+		shardConfig, err := ReadINI(filepath.Join(shardDir, ServerINI))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", cluster.Name, err)
+		}
+		modOverrides, err := os.ReadFile(filepath.Join(shardDir, ModOverrides))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("cluster %s: %w", cluster.Name, err)
+		}
+
+		cluster.Shards = append(cluster.Shards, &Shard{Name: entry.Name(), Dir: shardDir, Config: shardConfig, ModOverrides: string(modOverrides)})
+	}
+
+	// master goes first
+	slices.SortStableFunc(cluster.Shards, func(a, b *Shard) int {
+		if a.IsMaster() == b.IsMaster() {
+			return 0
+		} else if a.IsMaster() {
+			return -1
+		}
+		return 1
+	})
+
+	return cluster, nil
+}
+
+// tokenPattern matches cluster tokens generated by klei, like pds-g^KU_abc123XY^Zm9vYmFyCg==
+var tokenPattern = regexp.MustCompile(`^pds-g\^KU_[0-9A-Za-z_-]+\^[0-9A-Za-z+/=]+$`)
+
+// ValidateToken checks the cluster token exists and looks like one generated by klei,
+// whether klei accepts it is only known after the server started.
+func (c *Cluster) ValidateToken() error {
+	if len(c.Token) == 0 {
+		return errcode.Errorf(errcode.TokenInvalid, "cluster %s: %s is missing or empty", c.Name, ClusterToken)
+	}
+	if !tokenPattern.MatchString(c.Token) {
+		return errcode.Errorf(errcode.TokenInvalid, "cluster %s: malformed token in %s", c.Name, ClusterToken)
+	}
+	return nil
+}
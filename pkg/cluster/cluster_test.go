@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes files relative to dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Cluster_1/cluster.ini":               sampleClusterINI,
+		"Cluster_1/cluster_token.txt":         "pds-g^KU_abc123XY^Zm9vYmFyCg==\n",
+		"Cluster_1/Caves/server.ini":          "[SHARD]\nis_master = false\nname = Caves\n",
+		"Cluster_1/Master/server.ini":         "[SHARD]\nis_master = true\n\n[NETWORK]\nserver_port = 10999\n",
+		"Cluster_1/Master/modoverrides.lua":   "return {}",
+		"Cluster_1/save/session/placeholder":  "",
+		"Cluster_2/cluster.ini":               "[GAMEPLAY]\ngame_mode = endless\n",
+		"Cluster_2/Master/server.ini":         "[SHARD]\nis_master = true\n",
+		"Broken/cluster.ini":                  "[GAMEPLAY\n",
+		"Agreements/DoNotStarveTogether/a.md": "",
+		"client_save/settings.ini":            "[misc]\n",
+	})
+
+	// the malformed cluster is reported, the others are still discovered
+	clusters, err := Discover(dir)
+	t.Log(err)
+	require.ErrorContains(t, err, filepath.Join("Broken", ClusterINI))
+	require.Len(t, clusters, 2)
+
+	cluster := clusters[0]
+	require.Equal(t, "Cluster_1", cluster.Name)
+	require.Equal(t, "pds-g^KU_abc123XY^Zm9vYmFyCg==", cluster.Token)
+	require.Len(t, cluster.Shards, 2)
+	require.Equal(t, "Master", cluster.Shards[0].Name)
+	require.True(t, cluster.Shards[0].IsMaster())
+	require.False(t, cluster.Shard("Caves").IsMaster())
+	require.Nil(t, cluster.Shard("Island"))
+
+	require.Equal(t, "Cluster_2", clusters[1].Name)
+	require.Empty(t, clusters[1].Token)
+
+	_, err = Load(filepath.Join(dir, "client_save"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCluster_ValidateToken(t *testing.T) {
+	cluster := &Cluster{Name: "Cluster_1", Token: "pds-g^KU_abc123XY^Zm9vYmFyCg=="}
+	require.NoError(t, cluster.ValidateToken())
+
+	for _, token := range []string{"", "KU_abc123XY", "pds-g^KU_abc123XY^", "pds-g^KU_abc 123^Zm9v"} {
+		cluster.Token = token
+		err := cluster.ValidateToken()
+		t.Log(err)
+		require.Equal(t, errcode.TokenInvalid, errcode.Of(err), token)
+	}
+}
@@ -0,0 +1,414 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+)
+
+// ClusterConfig is the typed content of cluster.ini
+type ClusterConfig struct {
+	Gameplay GameplayConfig       `ini:"GAMEPLAY"`
+	Network  ClusterNetworkConfig `ini:"NETWORK"`
+	Misc     MiscConfig           `ini:"MISC"`
+	Shard    ClusterShardConfig   `ini:"SHARD"`
+	Steam    ClusterSteamConfig   `ini:"STEAM"`
+}
+
+// GameplayConfig is [GAMEPLAY] of cluster.ini
+type GameplayConfig struct {
+	GameMode       string `ini:"game_mode" default:"survival"`
+	MaxPlayers     int    `ini:"max_players" default:"16"`
+	PVP            bool   `ini:"pvp" default:"false"`
+	PauseWhenEmpty bool   `ini:"pause_when_empty" default:"false"`
+	VoteEnabled    bool   `ini:"vote_enabled" default:"true"`
+}
+
+// ClusterNetworkConfig is [NETWORK] of cluster.ini
+type ClusterNetworkConfig struct {
+	ClusterName        string `ini:"cluster_name"`
+	ClusterDescription string `ini:"cluster_description"`
+	ClusterPassword    string `ini:"cluster_password"`
+	ClusterIntention   string `ini:"cluster_intention"`
+	ClusterLanguage    string `ini:"cluster_language" default:"en"`
+	LanOnlyCluster     bool   `ini:"lan_only_cluster" default:"false"`
+	OfflineCluster     bool   `ini:"offline_cluster" default:"false"`
+	AutosaverEnabled   bool   `ini:"autosaver_enabled" default:"true"`
+	TickRate           int    `ini:"tick_rate" default:"15"`
+	WhitelistSlots     int    `ini:"whitelist_slots" default:"0"`
+}
+
+// MiscConfig is [MISC] of cluster.ini
+type MiscConfig struct {
+	MaxSnapshots   int  `ini:"max_snapshots" default:"6"`
+	ConsoleEnabled bool `ini:"console_enabled" default:"true"`
+}
+
+// ClusterShardConfig is [SHARD] of cluster.ini
+type ClusterShardConfig struct {
+	ShardEnabled bool   `ini:"shard_enabled" default:"false"`
+	BindIP       string `ini:"bind_ip" default:"127.0.0.1"`
+	MasterIP     string `ini:"master_ip"`
+	MasterPort   int    `ini:"master_port" default:"10888"`
+	ClusterKey   string `ini:"cluster_key"`
+}
+
+// ClusterSteamConfig is [STEAM] of cluster.ini
+type ClusterSteamConfig struct {
+	SteamGroupID     string `ini:"steam_group_id" default:"0"`
+	SteamGroupOnly   bool   `ini:"steam_group_only" default:"false"`
+	SteamGroupAdmins bool   `ini:"steam_group_admins" default:"false"`
+}
+
+// ServerConfig is the typed content of server.ini of a shard
+type ServerConfig struct {
+	Network ServerNetworkConfig `ini:"NETWORK"`
+	Shard   ServerShardConfig   `ini:"SHARD"`
+	Steam   ServerSteamConfig   `ini:"STEAM"`
+	Account AccountConfig       `ini:"ACCOUNT"`
+}
+
+// ServerNetworkConfig is [NETWORK] of server.ini
+type ServerNetworkConfig struct {
+	ServerPort int `ini:"server_port" default:"10999"`
+}
+
+// ServerShardConfig is [SHARD] of server.ini
+type ServerShardConfig struct {
+	IsMaster bool   `ini:"is_master" default:"false"`
+	Name     string `ini:"name"`
+	ID       string `ini:"id"`
+}
+
+// ServerSteamConfig is [STEAM] of server.ini
+type ServerSteamConfig struct {
+	MasterServerPort   int `ini:"master_server_port" default:"27016"`
+	AuthenticationPort int `ini:"authentication_port" default:"8766"`
+}
+
+// AccountConfig is [ACCOUNT] of server.ini
+type AccountConfig struct {
+	EncodeUserPath bool `ini:"encode_user_path" default:"false"`
+}
+
+// Warning is a problem of ini which does not prevent the server from starting
+type Warning struct {
+	Section string
+	Key     string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("[%s] %s: %s", w.Section, w.Key, w.Message)
+}
+
+// clusterDeprecatedKeys are the keys of cluster.ini removed from the server, mostly left over from settings.ini before clusters
+var clusterDeprecatedKeys = map[[2]string]string{
+	{"NETWORK", "default_server_name"}: "removed, use cluster_name instead",
+	{"NETWORK", "server_description"}:  "removed, use cluster_description instead",
+	{"NETWORK", "server_password"}:     "removed, use cluster_password instead",
+	{"NETWORK", "server_intention"}:    "removed, use cluster_intention instead",
+	{"NETWORK", "enable_autosaver"}:    "removed, use autosaver_enabled instead",
+	{"NETWORK", "enable_vote_kick"}:    "removed, use vote_enabled in [GAMEPLAY] instead",
+	{"NETWORK", "game_mode"}:           "moved to [GAMEPLAY]",
+	{"NETWORK", "max_players"}:         "moved to [GAMEPLAY]",
+	{"NETWORK", "pvp"}:                 "moved to [GAMEPLAY]",
+	{"NETWORK", "pause_when_empty"}:    "moved to [GAMEPLAY]",
+}
+
+// serverDeprecatedKeys are the keys of server.ini which belong to cluster.ini since clusters
+var serverDeprecatedKeys = map[[2]string]string{
+	{"NETWORK", "server_password"}: "removed, use cluster_password of cluster.ini instead",
+	{"NETWORK", "max_players"}:     "moved to [GAMEPLAY] of cluster.ini",
+	{"NETWORK", "pvp"}:             "moved to [GAMEPLAY] of cluster.ini",
+	{"NETWORK", "game_mode"}:       "moved to [GAMEPLAY] of cluster.ini",
+	{"SHARD", "shard_enabled"}:     "moved to cluster.ini",
+	{"SHARD", "bind_ip"}:           "moved to cluster.ini",
+	{"SHARD", "master_ip"}:         "moved to cluster.ini",
+	{"SHARD", "master_port"}:       "moved to cluster.ini",
+	{"SHARD", "cluster_key"}:       "moved to cluster.ini",
+}
+
+var (
+	gameModes         = []string{"survival", "endless", "wilderness", "lavaarena", "quagmire"}
+	clusterIntentions = []string{"", "cooperative", "competitive", "social", "madness"}
+)
+
+// ParseClusterConfig decodes cluster.ini, absent keys take the defaults of the server,
+// it returns warnings for deprecated and unknown keys, and error for values of wrong type.
+func ParseClusterConfig(ini *INI) (*ClusterConfig, []Warning, error) {
+	var config ClusterConfig
+	warnings, err := decode(ini, &config, clusterDeprecatedKeys)
+	if err != nil {
+		return nil, warnings, errcode.Wrap(errcode.ConfigInvalid, err)
+	}
+	return &config, warnings, nil
+}
+
+// Validate checks the values are accepted by the server
+func (c *ClusterConfig) Validate() error {
+	var errs []error
+
+	if !slices.Contains(gameModes, c.Gameplay.GameMode) {
+		errs = append(errs, fmt.Errorf("[GAMEPLAY] game_mode: unknown mode %q", c.Gameplay.GameMode))
+	}
+	if c.Gameplay.MaxPlayers < 1 || c.Gameplay.MaxPlayers > 64 {
+		errs = append(errs, fmt.Errorf("[GAMEPLAY] max_players: %d out of range [1, 64]", c.Gameplay.MaxPlayers))
+	}
+
+	if !slices.Contains(clusterIntentions, c.Network.ClusterIntention) {
+		errs = append(errs, fmt.Errorf("[NETWORK] cluster_intention: unknown intention %q", c.Network.ClusterIntention))
+	}
+	if c.Network.TickRate < 1 || c.Network.TickRate > 60 {
+		errs = append(errs, fmt.Errorf("[NETWORK] tick_rate: %d out of range [1, 60]", c.Network.TickRate))
+	}
+	if c.Network.WhitelistSlots < 0 || c.Network.WhitelistSlots > c.Gameplay.MaxPlayers {
+		errs = append(errs, fmt.Errorf("[NETWORK] whitelist_slots: %d out of range [0, max_players]", c.Network.WhitelistSlots))
+	}
+
+	if c.Misc.MaxSnapshots < 0 {
+		errs = append(errs, fmt.Errorf("[MISC] max_snapshots: %d is negative", c.Misc.MaxSnapshots))
+	}
+
+	if c.Shard.ShardEnabled {
+		if len(c.Shard.ClusterKey) == 0 {
+			errs = append(errs, errors.New("[SHARD] cluster_key: required if shard_enabled"))
+		}
+		if net.ParseIP(c.Shard.BindIP) == nil {
+			errs = append(errs, fmt.Errorf("[SHARD] bind_ip: invalid ip %q", c.Shard.BindIP))
+		}
+		if len(c.Shard.MasterIP) > 0 && net.ParseIP(c.Shard.MasterIP) == nil {
+			errs = append(errs, fmt.Errorf("[SHARD] master_ip: invalid ip %q", c.Shard.MasterIP))
+		}
+		if !validPort(c.Shard.MasterPort) {
+			errs = append(errs, fmt.Errorf("[SHARD] master_port: invalid port %d", c.Shard.MasterPort))
+		}
+	}
+
+	if _, err := strconv.ParseUint(c.Steam.SteamGroupID, 10, 64); err != nil {
+		errs = append(errs, fmt.Errorf("[STEAM] steam_group_id: invalid id %q", c.Steam.SteamGroupID))
+	}
+
+	return errcode.Wrap(errcode.ConfigInvalid, errors.Join(errs...))
+}
+
+// Apply writes values into ini, a key is written only if it exists in ini or differs from the default
+func (c *ClusterConfig) Apply(ini *INI) {
+	encode(ini, c)
+}
+
+// ParseServerConfig decodes server.ini, see ParseClusterConfig
+func ParseServerConfig(ini *INI) (*ServerConfig, []Warning, error) {
+	var config ServerConfig
+	warnings, err := decode(ini, &config, serverDeprecatedKeys)
+	if err != nil {
+		return nil, warnings, errcode.Wrap(errcode.ConfigInvalid, err)
+	}
+	return &config, warnings, nil
+}
+
+// Validate checks the values are accepted by the server
+func (s *ServerConfig) Validate() error {
+	var errs []error
+
+	ports := map[string]int{
+		"[NETWORK] server_port":       s.Network.ServerPort,
+		"[STEAM] master_server_port":  s.Steam.MasterServerPort,
+		"[STEAM] authentication_port": s.Steam.AuthenticationPort,
+	}
+	used := make(map[int]string)
+	for _, key := range []string{"[NETWORK] server_port", "[STEAM] master_server_port", "[STEAM] authentication_port"} {
+		port := ports[key]
+		if !validPort(port) {
+			errs = append(errs, fmt.Errorf("%s: invalid port %d", key, port))
+		} else if other, ok := used[port]; ok {
+			errs = append(errs, fmt.Errorf("%s: port %d already used by %s", key, port, other))
+		}
+		used[port] = key
+	}
+
+	if !s.Shard.IsMaster && len(s.Shard.Name) == 0 {
+		errs = append(errs, errors.New("[SHARD] name: required if the shard is not master"))
+	}
+
+	return errcode.Wrap(errcode.ConfigInvalid, errors.Join(errs...))
+}
+
+// Apply writes values into ini, see ClusterConfig.Apply
+func (s *ServerConfig) Apply(ini *INI) {
+	encode(ini, s)
+}
+
+// ClusterConfig parses cluster.ini of the cluster
+func (c *Cluster) ClusterConfig() (*ClusterConfig, []Warning, error) {
+	return ParseClusterConfig(c.Config)
+}
+
+// ServerConfig parses server.ini of the shard
+func (s *Shard) ServerConfig() (*ServerConfig, []Warning, error) {
+	return ParseServerConfig(s.Config)
+}
+
+// CheckPorts checks the ports of the shards are free on the host and not shared between shards,
+// so it must be called before the shards start. Ports of the game, steam and shards are all udp.
+func (c *Cluster) CheckPorts() error {
+	type shardPort struct {
+		key  string
+		port int
+	}
+
+	var ports []shardPort
+	clusterConfig, _, err := c.ClusterConfig()
+	if err != nil {
+		return err
+	}
+	if clusterConfig.Shard.ShardEnabled {
+		ports = append(ports, shardPort{"cluster.ini [SHARD] master_port", clusterConfig.Shard.MasterPort})
+	}
+	for _, shard := range c.Shards {
+		config, _, err := shard.ServerConfig()
+		if err != nil {
+			return fmt.Errorf("shard %s: %w", shard.Name, err)
+		}
+		ports = append(ports,
+			shardPort{"shard " + shard.Name + " [NETWORK] server_port", config.Network.ServerPort},
+			shardPort{"shard " + shard.Name + " [STEAM] master_server_port", config.Steam.MasterServerPort},
+			shardPort{"shard " + shard.Name + " [STEAM] authentication_port", config.Steam.AuthenticationPort},
+		)
+	}
+
+	var errs []error
+	used := make(map[int]string)
+	for _, port := range ports {
+		if !validPort(port.port) {
+			// reported by Validate
+			continue
+		}
+		if other, ok := used[port.port]; ok {
+			errs = append(errs, errcode.Errorf(errcode.PortInUse, "%s: port %d already used by %s", port.key, port.port, other))
+			continue
+		}
+		used[port.port] = port.key
+
+		conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", port.port))
+		if err != nil {
+			errs = append(errs, errcode.Errorf(errcode.PortInUse, "%s: port %d is in use: %w", port.key, port.port, err))
+			continue
+		}
+		conn.Close()
+	}
+	return errors.Join(errs...)
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// decode decodes ini into config by ini and default tags, config must be a pointer to
+// struct whose fields are section structs with string, bool or int fields.
+// Keys in deprecated are warned with their messages, other keys not in config are warned as unknown.
+func decode(ini *INI, config any, deprecated map[[2]string]string) ([]Warning, error) {
+	var (
+		warnings []Warning
+		errs     []error
+	)
+
+	known := make(map[[2]string]bool)
+	configValue := reflect.ValueOf(config).Elem()
+	for i := 0; i < configValue.NumField(); i++ {
+		sectionName := configValue.Type().Field(i).Tag.Get("ini")
+		sectionValue := configValue.Field(i)
+
+		for j := 0; j < sectionValue.NumField(); j++ {
+			field := sectionValue.Type().Field(j)
+			key := field.Tag.Get("ini")
+			known[[2]string{sectionName, key}] = true
+
+			value, ok := ini.Get(sectionName, key)
+			if !ok {
+				value = field.Tag.Get("default")
+			}
+			if err := setValue(sectionValue.Field(j), value); err != nil {
+				errs = append(errs, fmt.Errorf("[%s] %s: %w", sectionName, key, err))
+			}
+		}
+	}
+
+	for _, section := range ini.Sections() {
+		for _, key := range section.Keys() {
+			id := [2]string{section.Name, key}
+			if message, ok := deprecated[id]; ok {
+				warnings = append(warnings, Warning{Section: section.Name, Key: key, Message: "deprecated: " + message})
+			} else if !known[id] {
+				warnings = append(warnings, Warning{Section: section.Name, Key: key, Message: "unknown key"})
+			}
+		}
+	}
+
+	return warnings, errors.Join(errs...)
+}
+
+// encode writes config into ini, see decode
+func encode(ini *INI, config any) {
+	configValue := reflect.ValueOf(config).Elem()
+	for i := 0; i < configValue.NumField(); i++ {
+		sectionName := configValue.Type().Field(i).Tag.Get("ini")
+		sectionValue := configValue.Field(i)
+
+		for j := 0; j < sectionValue.NumField(); j++ {
+			field := sectionValue.Type().Field(j)
+			key := field.Tag.Get("ini")
+			value := formatValue(sectionValue.Field(j))
+
+			if _, ok := ini.Get(sectionName, key); ok || value != field.Tag.Get("default") {
+				ini.Set(sectionName, key, value)
+			}
+		}
+	}
+}
+
+func setValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		if len(value) == 0 {
+			field.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		if len(value) == 0 {
+			field.SetInt(0)
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(int64(n))
+	default:
+		return fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+	return nil
+}
+
+func formatValue(field reflect.Value) string {
+	switch field.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool())
+	case reflect.Int:
+		return strconv.Itoa(int(field.Int()))
+	default:
+		return field.String()
+	}
+}
@@ -0,0 +1,189 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClusterConfig(t *testing.T) {
+	ini, err := ParseINI(strings.NewReader(sampleClusterINI + `
+[NETWORK]
+default_server_name = Old Name
+clustr_description = typo
+`))
+	require.NoError(t, err)
+	ini.Set("SHARD", "cluster_key", "secret")
+
+	config, warnings, err := ParseClusterConfig(ini)
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+
+	require.Equal(t, "survival", config.Gameplay.GameMode)
+	require.Equal(t, 6, config.Gameplay.MaxPlayers)
+	require.True(t, config.Gameplay.VoteEnabled)
+	require.Equal(t, "My Cluster = Best", config.Network.ClusterName)
+	require.Equal(t, 15, config.Network.TickRate)
+	require.Equal(t, 6, config.Misc.MaxSnapshots)
+	require.True(t, config.Shard.ShardEnabled)
+	require.Equal(t, 10888, config.Shard.MasterPort)
+
+	require.Len(t, warnings, 2)
+	require.Equal(t, "default_server_name", warnings[0].Key)
+	require.Contains(t, warnings[0].Message, "deprecated")
+	require.Equal(t, "clustr_description", warnings[1].Key)
+	require.Equal(t, "unknown key", warnings[1].Message)
+	for _, warning := range warnings {
+		t.Log(warning)
+	}
+}
+
+func TestParseClusterConfig_Invalid(t *testing.T) {
+	ini, err := ParseINI(strings.NewReader("[GAMEPLAY]\nmax_players = many\npvp = yes\n"))
+	require.NoError(t, err)
+
+	_, _, err = ParseClusterConfig(ini)
+	require.ErrorContains(t, err, "max_players")
+	require.ErrorContains(t, err, "pvp")
+
+	ini, err = ParseINI(strings.NewReader(sampleClusterINI))
+	require.NoError(t, err)
+	ini.Set("GAMEPLAY", "game_mode", "hardcore")
+	ini.Set("GAMEPLAY", "max_players", "100")
+	ini.Set("SHARD", "bind_ip", "localhost")
+
+	config, _, err := ParseClusterConfig(ini)
+	require.NoError(t, err)
+	err = config.Validate()
+	require.ErrorContains(t, err, "game_mode")
+	require.ErrorContains(t, err, "max_players")
+	require.ErrorContains(t, err, "bind_ip")
+	require.ErrorContains(t, err, "cluster_key")
+	t.Log(err)
+}
+
+func TestClusterConfig_Apply(t *testing.T) {
+	ini, err := ParseINI(strings.NewReader(sampleClusterINI))
+	require.NoError(t, err)
+
+	config, _, err := ParseClusterConfig(ini)
+	require.NoError(t, err)
+	config.Gameplay.MaxPlayers = 12
+	config.Gameplay.PVP = true
+	config.Shard.ClusterKey = "secret"
+	config.Apply(ini)
+
+	var buf bytes.Buffer
+	_, err = ini.WriteTo(&buf)
+	require.NoError(t, err)
+	t.Log(buf.String())
+
+	// unchanged defaults are not written
+	_, ok := ini.Get("MISC", "max_snapshots")
+	require.False(t, ok)
+
+	reparsed, err := ParseINI(&buf)
+	require.NoError(t, err)
+	applied, _, err := ParseClusterConfig(reparsed)
+	require.NoError(t, err)
+	require.Equal(t, config, applied)
+}
+
+func TestParseServerConfig(t *testing.T) {
+	ini, err := ParseINI(strings.NewReader("[NETWORK]\nserver_port = 11000\n\n[SHARD]\nis_master = false\n"))
+	require.NoError(t, err)
+
+	config, warnings, err := ParseServerConfig(ini)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	require.Equal(t, 11000, config.Network.ServerPort)
+	require.Equal(t, 27016, config.Steam.MasterServerPort)
+	require.Equal(t, 8766, config.Steam.AuthenticationPort)
+	require.ErrorContains(t, config.Validate(), "name")
+
+	config.Shard.Name = "Caves"
+	config.Steam.AuthenticationPort = 11000
+	require.ErrorContains(t, config.Validate(), "already used")
+
+	config.Steam.AuthenticationPort = 8767
+	require.NoError(t, config.Validate())
+}
+
+func TestParseServerConfig_Deprecated(t *testing.T) {
+	ini, err := ParseINI(strings.NewReader("[NETWORK]\nserver_port = 11000\ndefault_server_name = Old Name\n\n[SHARD]\nis_master = true\ncluster_key = secret\n"))
+	require.NoError(t, err)
+
+	_, warnings, err := ParseServerConfig(ini)
+	require.NoError(t, err)
+	for _, warning := range warnings {
+		t.Log(warning)
+	}
+
+	// keys deprecated in cluster.ini are unknown in server.ini
+	require.Equal(t, []Warning{
+		{Section: "NETWORK", Key: "default_server_name", Message: "unknown key"},
+		{Section: "SHARD", Key: "cluster_key", Message: "deprecated: moved to cluster.ini"},
+	}, warnings)
+}
+
+func TestDecode_UnsupportedKind(t *testing.T) {
+	ini, err := ParseINI(strings.NewReader("[MISC]\nratio = 0.5\n"))
+	require.NoError(t, err)
+
+	var config struct {
+		Misc struct {
+			Ratio float64 `ini:"ratio"`
+		} `ini:"MISC"`
+	}
+	_, err = decode(ini, &config, nil)
+	require.ErrorContains(t, err, "[MISC] ratio: unsupported field kind float64")
+}
+
+// freeUDPPort returns a udp port which was free a moment ago
+func freeUDPPort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp", ":0")
+	require.NoError(t, err)
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestCluster_CheckPorts(t *testing.T) {
+	serverINI := func(port, masterServerPort, authenticationPort int) string {
+		return fmt.Sprintf("[SHARD]\nis_master = true\n\n[NETWORK]\nserver_port = %d\n\n[STEAM]\nmaster_server_port = %d\nauthentication_port = %d\n",
+			port, masterServerPort, authenticationPort)
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cluster.ini":       "[SHARD]\nshard_enabled = false\n",
+		"Master/server.ini": serverINI(freeUDPPort(t), freeUDPPort(t), freeUDPPort(t)),
+	})
+	cluster, err := Load(dir)
+	require.NoError(t, err)
+	require.NoError(t, cluster.CheckPorts())
+
+	// a port bound by another process, and a port shared by shards
+	conn, err := net.ListenPacket("udp", ":0")
+	require.NoError(t, err)
+	defer conn.Close()
+	busy := conn.LocalAddr().(*net.UDPAddr).Port
+	shared := freeUDPPort(t)
+
+	writeFiles(t, dir, map[string]string{
+		"Master/server.ini": serverINI(busy, shared, freeUDPPort(t)),
+		"Caves/server.ini":  serverINI(freeUDPPort(t), shared, freeUDPPort(t)),
+	})
+	cluster, err = Load(dir)
+	require.NoError(t, err)
+
+	err = cluster.CheckPorts()
+	t.Log(err)
+	require.Equal(t, errcode.PortInUse, errcode.Of(err))
+	require.ErrorContains(t, err, fmt.Sprintf("port %d is in use", busy))
+	require.ErrorContains(t, err, fmt.Sprintf("port %d already used by", shared))
+}
@@ -0,0 +1,197 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// INI is a parsed ini file, it keeps the order of sections and keys so that it can be written back as is
+type INI struct {
+	sections []*Section
+}
+
+// Section is a named group of key values in ini file, keys before the first section belong to the section named ""
+type Section struct {
+	Name   string
+	keys   []string
+	values map[string]string
+}
+
+// Keys returns keys of the section in order
+func (s *Section) Keys() []string {
+	return slices.Clone(s.keys)
+}
+
+// Get returns value of the key
+func (s *Section) Get(key string) (string, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set sets value of the key, new key is appended to the end of section
+func (s *Section) Set(key, value string) {
+	if _, ok := s.values[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.values[key] = value
+}
+
+// Delete deletes the key
+func (s *Section) Delete(key string) {
+	if _, ok := s.values[key]; !ok {
+		return
+	}
+	delete(s.values, key)
+	s.keys = slices.DeleteFunc(s.keys, func(k string) bool { return k == key })
+}
+
+// ReadINI reads and parses ini file
+func ReadINI(file string) (*INI, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	ini, err := ParseINI(fd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return ini, nil
+}
+
+// utf8BOM is the byte order mark some editors on windows write at the start of ini files
+const utf8BOM = "\ufeff"
+
+// ParseINI parses ini content, lines starting with ; or # are comments. A BOM at the start of content
+// is skipped, BOMs around keys are trimmed like spaces, so a key of BOMs only is an empty key.
+func ParseINI(reader io.Reader) (*INI, error) {
+	ini := &INI{}
+	var section *Section
+
+	buffered := bufio.NewReader(reader)
+	if bom, err := buffered.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		_, _ = buffered.Discard(len(utf8BOM))
+	}
+
+	scanner := bufio.NewScanner(buffered)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("line %d: invalid section %q", n, line)
+			}
+			section = ini.AddSection(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid key value %q", n, line)
+		}
+		key = strings.TrimFunc(key, isKeySpace)
+		if len(key) == 0 {
+			return nil, fmt.Errorf("line %d: empty key", n)
+		}
+
+		if section == nil {
+			section = ini.AddSection("")
+		}
+		section.Set(key, strings.TrimSpace(value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ini, nil
+}
+
+// isKeySpace reports whether r is trimmed around keys
+func isKeySpace(r rune) bool {
+	return unicode.IsSpace(r) || r == '\ufeff'
+}
+
+// Sections returns all sections in order
+func (i *INI) Sections() []*Section {
+	return slices.Clone(i.sections)
+}
+
+// Section returns the named section, or nil if not exist
+func (i *INI) Section(name string) *Section {
+	for _, section := range i.sections {
+		if section.Name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// AddSection returns the named section, creates it if not exist
+func (i *INI) AddSection(name string) *Section {
+	if section := i.Section(name); section != nil {
+		return section
+	}
+	section := &Section{Name: name, values: make(map[string]string)}
+	// keys without section must be written before any section header
+	if len(name) == 0 {
+		i.sections = slices.Insert(i.sections, 0, section)
+	} else {
+		i.sections = append(i.sections, section)
+	}
+	return section
+}
+
+// Get returns value of the key in section
+func (i *INI) Get(section, key string) (string, bool) {
+	s := i.Section(section)
+	if s == nil {
+		return "", false
+	}
+	return s.Get(key)
+}
+
+// Set sets value of the key in section, the section is created if not exist
+func (i *INI) Set(section, key, value string) {
+	i.AddSection(section).Set(key, value)
+}
+
+// WriteTo writes ini content into writer
+func (i *INI) WriteTo(writer io.Writer) (int64, error) {
+	var builder strings.Builder
+	for n, section := range i.sections {
+		if n > 0 {
+			builder.WriteString("\n")
+		}
+		if len(section.Name) > 0 {
+			builder.WriteString("[" + section.Name + "]\n")
+		}
+		for _, key := range section.keys {
+			builder.WriteString(key + " = " + section.values[key] + "\n")
+		}
+	}
+
+	written, err := io.WriteString(writer, builder.String())
+	return int64(written), err
+}
+
+// WriteFile writes ini content into file
+func (i *INI) WriteFile(file string) error {
+	fd, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	_, err = i.WriteTo(fd)
+	return err
+}
@@ -0,0 +1,119 @@
+package cluster
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleClusterINI = `[GAMEPLAY]
+game_mode = survival
+max_players = 6
+pvp = false
+
+; network settings
+[NETWORK]
+cluster_name = My Cluster = Best
+cluster_password =
+tick_rate = 15
+
+[SHARD]
+shard_enabled = true
+bind_ip = 127.0.0.1
+`
+
+func TestParseINI(t *testing.T) {
+	ini, err := ParseINI(strings.NewReader("\ufeff" + sampleClusterINI))
+	require.NoError(t, err)
+
+	var names []string
+	for _, section := range ini.Sections() {
+		names = append(names, section.Name)
+	}
+	require.Equal(t, []string{"GAMEPLAY", "NETWORK", "SHARD"}, names)
+
+	value, ok := ini.Get("NETWORK", "cluster_name")
+	require.True(t, ok)
+	require.Equal(t, "My Cluster = Best", value)
+
+	value, ok = ini.Get("NETWORK", "cluster_password")
+	require.True(t, ok)
+	require.Empty(t, value)
+
+	_, ok = ini.Get("MISC", "console_enabled")
+	require.False(t, ok)
+
+	ini.Set("GAMEPLAY", "max_players", "12")
+	ini.Set("MISC", "console_enabled", "true")
+	ini.Section("GAMEPLAY").Delete("pvp")
+	ini.Set("", "global", "1")
+
+	var builder strings.Builder
+	_, err = ini.WriteTo(&builder)
+	require.NoError(t, err)
+	t.Log(builder.String())
+
+	reparsed, err := ParseINI(strings.NewReader(builder.String()))
+	require.NoError(t, err)
+	require.Equal(t, ini, reparsed)
+	require.Equal(t, []string{"game_mode", "max_players"}, reparsed.Section("GAMEPLAY").Keys())
+}
+
+func TestParseINI_BOM(t *testing.T) {
+	ini, err := ParseINI(strings.NewReader("\ufeff[SHARD]\n\ufeffname\ufeff = Caves\n"))
+	require.NoError(t, err)
+	value, ok := ini.Get("SHARD", "name")
+	require.True(t, ok)
+	require.Equal(t, "Caves", value)
+}
+
+func TestParseINI_Invalid(t *testing.T) {
+	samples := []string{
+		"[GAMEPLAY\nmax_players = 6",
+		"[GAMEPLAY]\nmax_players",
+		"[GAMEPLAY]\n = 6",
+		// only one BOM at the start is skipped, the rest is a key of BOMs only
+		"\ufeff\ufeff=",
+	}
+	for _, sample := range samples {
+		_, err := ParseINI(strings.NewReader(sample))
+		require.Error(t, err)
+		t.Log(err)
+	}
+}
+
+func FuzzParseINI(f *testing.F) {
+	f.Add(sampleClusterINI)
+	f.Add("\ufeff" + sampleClusterINI)
+	f.Add("key = value\n[]\n[SHARD]\r\nname = Caves\r\n")
+	f.Add("[GAMEPLAY\nmax_players = 6")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		ini, err := ParseINI(strings.NewReader(content))
+		if err != nil {
+			return
+		}
+
+		// written content must be parsed back into the same ini
+		var buf bytes.Buffer
+		_, err = ini.WriteTo(&buf)
+		require.NoError(t, err)
+
+		reparsed, err := ParseINI(&buf)
+		require.NoError(t, err, buf.String())
+		for _, section := range ini.Sections() {
+			// section without name is not written if it has no keys
+			if len(section.Name) == 0 && len(section.Keys()) == 0 {
+				continue
+			}
+			require.Equal(t, section.Keys(), reparsed.Section(section.Name).Keys(), buf.String())
+			for _, key := range section.Keys() {
+				want, _ := section.Get(key)
+				got, _ := reparsed.Get(section.Name, key)
+				require.Equal(t, want, got, buf.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerLog is the log file the server writes in shard directory
+const ServerLog = "server_log.txt"
+
+var (
+	// logTimePattern matches the elapsed time since the server started, like [01:05:12]:
+	logTimePattern = regexp.MustCompile(`^\[(\d+):(\d{2}):(\d{2})\]: `)
+	// logStartPattern matches the wall time the server printed on start up
+	logStartPattern = regexp.MustCompile(`^\[\d+:\d{2}:\d{2}\]: Current time: (.+)$`)
+)
+
+// ShardLog is the log of a shard to be merged
+type ShardLog struct {
+	Shard  string
+	Reader io.Reader
+	// wall time the server started, read from the "Current time:" line of the log if zero
+	Start time.Time
+}
+
+// LogLine is a line of merged logs
+type LogLine struct {
+	Shard string
+	// wall time of the line, zero if the start time of the shard is unknown
+	Time time.Time
+	// elapsed time since the shard started, lines without timestamp inherit it from the previous line
+	Elapsed time.Duration
+	Line    string
+}
+
+// MergeLogs merges the logs of shards into one list ordered by time, lines of the same shard keep their order.
+// Lines are ordered by elapsed time instead if the start time of any shard is unknown.
+func MergeLogs(logs []ShardLog) ([]LogLine, error) {
+	var (
+		lines   []LogLine
+		unknown bool
+	)
+	for _, log := range logs {
+		shardLines, start, err := readLog(log)
+		if err != nil {
+			return nil, fmt.Errorf("shard %s: %w", log.Shard, err)
+		}
+		if start.IsZero() {
+			unknown = true
+		}
+		lines = append(lines, shardLines...)
+	}
+
+	slices.SortStableFunc(lines, func(a, b LogLine) int {
+		if unknown {
+			return cmp.Compare(a.Elapsed, b.Elapsed)
+		}
+		return a.Time.Compare(b.Time)
+	})
+	return lines, nil
+}
+
+// MergedLogs merges server_log.txt of all shards of cluster, see MergeLogs, shards without log are skipped
+func (c *Cluster) MergedLogs() ([]LogLine, error) {
+	var logs []ShardLog
+	for _, shard := range c.Shards {
+		fd, err := os.Open(filepath.Join(shard.Dir, ServerLog))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+
+		logs = append(logs, ShardLog{Shard: shard.Name, Reader: fd})
+	}
+	return MergeLogs(logs)
+}
+
+func readLog(log ShardLog) ([]LogLine, time.Time, error) {
+	start := log.Start
+	var (
+		lines   []LogLine
+		elapsed time.Duration
+	)
+
+	scanner := bufio.NewScanner(log.Reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if match := logTimePattern.FindStringSubmatch(line); match != nil {
+			hours, _ := strconv.Atoi(match[1])
+			minutes, _ := strconv.Atoi(match[2])
+			seconds, _ := strconv.Atoi(match[3])
+			elapsed = time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+		}
+		if start.IsZero() {
+			if match := logStartPattern.FindStringSubmatch(line); match != nil {
+				if t, err := time.ParseInLocation(time.ANSIC, strings.TrimSpace(match[1]), time.Local); err == nil {
+					start = t.Add(-elapsed)
+				}
+			}
+		}
+
+		lines = append(lines, LogLine{Shard: log.Shard, Elapsed: elapsed, Line: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, start, err
+	}
+
+	if !start.IsZero() {
+		for i := range lines {
+			lines[i].Time = start.Add(lines[i].Elapsed)
+		}
+	}
+	return lines, start, nil
+}
@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const masterLog = `[00:00:00]: Current time: Sat Oct 17 12:00:00 2026
+[00:00:10]: Sim paused
+[00:01:05]: [Shard] Migrating player KU_abcd1234 to shard Caves
+[00:01:20]: [Shard] Caves disconnected
+`
+
+const cavesLog = `[00:00:00]: Current time: Sat Oct 17 12:00:30 2026
+[00:00:36]: [Shard] Received migration of KU_abcd1234
+[00:00:37]: LUA ERROR stack traceback:
+    scripts/components/worldmigrator.lua:120
+`
+
+func TestMergeLogs(t *testing.T) {
+	lines, err := MergeLogs([]ShardLog{
+		{Shard: "Master", Reader: strings.NewReader(masterLog)},
+		{Shard: "Caves", Reader: strings.NewReader(cavesLog)},
+	})
+	require.NoError(t, err)
+
+	var merged []string
+	for _, line := range lines {
+		merged = append(merged, line.Shard+" "+line.Line)
+	}
+	require.Equal(t, []string{
+		"Master [00:00:00]: Current time: Sat Oct 17 12:00:00 2026",
+		"Master [00:00:10]: Sim paused",
+		"Caves [00:00:00]: Current time: Sat Oct 17 12:00:30 2026",
+		"Master [00:01:05]: [Shard] Migrating player KU_abcd1234 to shard Caves",
+		"Caves [00:00:36]: [Shard] Received migration of KU_abcd1234",
+		"Caves [00:00:37]: LUA ERROR stack traceback:",
+		"Caves     scripts/components/worldmigrator.lua:120",
+		"Master [00:01:20]: [Shard] Caves disconnected",
+	}, merged)
+
+	start := time.Date(2026, 10, 17, 12, 0, 30, 0, time.Local)
+	require.Equal(t, start.Add(37*time.Second), lines[6].Time)
+
+	// start of caves is unknown, ordered by elapsed time
+	lines, err = MergeLogs([]ShardLog{
+		{Shard: "Master", Reader: strings.NewReader(masterLog)},
+		{Shard: "Caves", Reader: strings.NewReader("[00:00:05]: Starting Up\n")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Caves", lines[1].Shard)
+	require.True(t, lines[1].Time.IsZero())
+}
+
+func TestCluster_MergedLogs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cluster.ini":           sampleClusterINI,
+		"Master/server.ini":     "[SHARD]\nis_master = true\n",
+		"Master/server_log.txt": masterLog,
+		"Caves/server.ini":      "[SHARD]\nis_master = false\nname = Caves\n",
+		"Caves/server_log.txt":  cavesLog,
+		"Forest/server.ini":     "[SHARD]\nis_master = false\nname = Forest\n",
+	})
+
+	cluster, err := Load(dir)
+	require.NoError(t, err)
+
+	lines, err := cluster.MergedLogs()
+	require.NoError(t, err)
+	require.Len(t, lines, 8)
+}
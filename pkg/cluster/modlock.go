@@ -0,0 +1,173 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+)
+
+// ModLockFile is the file of cluster which locks the installed workshop mods, see LockMods
+const ModLockFile = "mods.lock.json"
+
+// LockedMod is a workshop mod installed when the cluster was locked
+type LockedMod struct {
+	ID string `json:"id"`
+	// modification time of the newest file of the mod, which is when it was updated last
+	Updated time.Time `json:"updated"`
+	// sha256 of the paths and contents of all files of the mod
+	Hash string `json:"hash"`
+}
+
+// ModLock is the workshop mods of a cluster in id order, written into ModLockFile
+type ModLock struct {
+	Mods []LockedMod `json:"mods"`
+}
+
+// Mod returns the locked mod of id, or nil if not locked
+func (l *ModLock) Mod(id string) *LockedMod {
+	for i := range l.Mods {
+		if l.Mods[i].ID == id {
+			return &l.Mods[i]
+		}
+	}
+	return nil
+}
+
+// LockMods locks the workshop mods listed in modoverrides.lua of all shards as they are installed in modsDirs,
+// see CheckMods, and writes the lock into ModLockFile of the cluster. Lock after a profile is applied and
+// the mods are updated, so VerifyMods tells whether the same mods are installed at the next start.
+func (c *Cluster) LockMods(modsDirs ...string) (*ModLock, error) {
+	if err := c.CheckMods(modsDirs...); err != nil {
+		return nil, err
+	}
+
+	lock := &ModLock{Mods: []LockedMod{}}
+	for _, id := range c.workshopMods() {
+		dir, _ := modDir(modsDirs, id)
+		mod, err := lockMod(id, dir)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", c.Name, err)
+		}
+		lock.Mods = append(lock.Mods, mod)
+	}
+
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(c.Dir, ModLockFile), append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// ReadModLock reads ModLockFile of the cluster, returns error wraps os.ErrNotExist if the cluster is not locked
+func (c *Cluster) ReadModLock() (*ModLock, error) {
+	data, err := os.ReadFile(filepath.Join(c.Dir, ModLockFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var lock ModLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, errcode.Errorf(errcode.ConfigInvalid, "cluster %s: %s: %w", c.Name, ModLockFile, err)
+	}
+	return &lock, nil
+}
+
+// VerifyMods verifies the workshop mods installed in modsDirs match ModLockFile of the cluster, it returns
+// errcode.ModDrift listing the mods changed, not installed, not locked or no longer enabled, the caller
+// refuses to start on it or takes it as a warning. Nothing is verified if the cluster is not locked.
+func (c *Cluster) VerifyMods(modsDirs ...string) error {
+	lock, err := c.ReadModLock()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var drifts []string
+	enabled := c.workshopMods()
+	for _, id := range enabled {
+		locked := lock.Mod(id)
+		if locked == nil {
+			drifts = append(drifts, fmt.Sprintf("workshop-%s not locked", id))
+			continue
+		}
+
+		dir, ok := modDir(modsDirs, id)
+		if !ok {
+			drifts = append(drifts, fmt.Sprintf("workshop-%s not installed", id))
+			continue
+		}
+		mod, err := lockMod(id, dir)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %w", c.Name, err)
+		}
+		if mod.Hash != locked.Hash {
+			drifts = append(drifts, fmt.Sprintf("workshop-%s changed, updated at %s, locked at %s",
+				id, mod.Updated.Format(time.RFC3339), locked.Updated.Format(time.RFC3339)))
+		}
+	}
+	for _, locked := range lock.Mods {
+		if !slices.Contains(enabled, locked.ID) {
+			drifts = append(drifts, fmt.Sprintf("workshop-%s no longer enabled", locked.ID))
+		}
+	}
+
+	if len(drifts) > 0 {
+		return errcode.Errorf(errcode.ModDrift, "cluster %s: mods differ from %s: %s", c.Name, ModLockFile, strings.Join(drifts, ", "))
+	}
+	return nil
+}
+
+// lockMod hashes the files in dir of the workshop mod of id
+func lockMod(id, dir string) (LockedMod, error) {
+	mod := LockedMod{ID: id}
+	hash := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(mod.Updated) {
+			mod.Updated = info.ModTime()
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		// paths are separated from contents, so moving bytes between them changes the hash
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(rel), info.Size())
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(hash, file)
+		return err
+	})
+	if err != nil {
+		return LockedMod{}, fmt.Errorf("workshop-%s: %w", id, err)
+	}
+
+	mod.Hash = hex.EncodeToString(hash.Sum(nil))
+	return mod, nil
+}
@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_LockMods(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Cluster_1/cluster.ini":                         sampleClusterINI,
+		"Cluster_1/Master/server.ini":                   "[SHARD]\nis_master = true\n",
+		"Cluster_1/Master/modoverrides.lua":             sampleModOverrides,
+		"Cluster_1/Caves/server.ini":                    "[SHARD]\nis_master = false\nname = Caves\n",
+		"Cluster_1/Caves/modoverrides.lua":              sampleModOverrides,
+		"mods/workshop-378160973/modinfo.lua":           `name = "Global Positions"`,
+		"mods/workshop-378160973/scripts/positions.lua": "return {}",
+	})
+	mods := filepath.Join(dir, "mods")
+
+	cluster, err := Load(filepath.Join(dir, "Cluster_1"))
+	require.NoError(t, err)
+
+	// nothing to verify before locked
+	require.NoError(t, cluster.VerifyMods(mods))
+	_, err = cluster.ReadModLock()
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	lock, err := cluster.LockMods(mods)
+	require.NoError(t, err)
+	require.Len(t, lock.Mods, 1)
+	require.Equal(t, "378160973", lock.Mods[0].ID)
+	require.NotEmpty(t, lock.Mods[0].Hash)
+	require.False(t, lock.Mods[0].Updated.IsZero())
+
+	read, err := cluster.ReadModLock()
+	require.NoError(t, err)
+	require.Equal(t, lock.Mods[0].Hash, read.Mods[0].Hash)
+	require.NoError(t, cluster.VerifyMods(mods))
+
+	// the mod is updated
+	writeFiles(t, dir, map[string]string{"mods/workshop-378160973/scripts/positions.lua": "return { x = 1 }"})
+	err = cluster.VerifyMods(mods)
+	t.Log(err)
+	require.Equal(t, errcode.ModDrift, errcode.Of(err))
+	require.ErrorContains(t, err, "workshop-378160973 changed")
+
+	// the mod is removed, and another one is enabled without locking
+	require.NoError(t, os.RemoveAll(filepath.Join(mods, "workshop-378160973")))
+	cluster.Shard("Caves").ModOverrides = `return { ["workshop-1216718131"] = { enabled = true } }`
+	err = cluster.VerifyMods(mods)
+	t.Log(err)
+	require.Equal(t, errcode.ModDrift, errcode.Of(err))
+	require.ErrorContains(t, err, "workshop-378160973 not installed")
+	require.ErrorContains(t, err, "workshop-1216718131 not locked")
+
+	// mods not installed can not be locked
+	_, err = cluster.LockMods(mods)
+	require.Equal(t, errcode.ModMissing, errcode.Of(err))
+
+	cluster.Shard("Master").ModOverrides = ""
+	cluster.Shard("Caves").ModOverrides = ""
+	err = cluster.VerifyMods(mods)
+	require.ErrorContains(t, err, "workshop-378160973 no longer enabled")
+}
@@ -0,0 +1,160 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+)
+
+const (
+	// ModOverrides is the file of shard which enables and configures mods
+	ModOverrides = "modoverrides.lua"
+	// safeModeSuffix is appended to modoverrides.lua moved aside by DisableMods
+	safeModeSuffix = ".safemode"
+	// emptyModOverrides enables no mods
+	emptyModOverrides = "return {}\n"
+)
+
+// DisableMods moves modoverrides.lua of all shards aside and replaces them with empty ones,
+// so the cluster boots with mods disabled, the returned restore puts the original files back.
+// It fails if a file moved aside by the previous call still exists, which means it was not restored,
+// such as the manager exited in safe mode, call RestoreMods then. Only modoverrides.lua enables mods
+// of a shard, dedicated_server_mods_setup.lua of the server installation just downloads them.
+func (c *Cluster) DisableMods() (restore func() error, err error) {
+	for _, shard := range c.Shards {
+		backup := filepath.Join(shard.Dir, ModOverrides+safeModeSuffix)
+		if _, err := os.Stat(backup); err == nil {
+			return nil, errcode.Errorf(errcode.ModsAlreadyDisabled, "shard %s: mods are already disabled, found %s, restore it by RestoreMods", shard.Name, backup)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	var disabled []*Shard
+	restore = func() error {
+		var errs []error
+		for _, shard := range disabled {
+			file := filepath.Join(shard.Dir, ModOverrides)
+			if err := os.Rename(file+safeModeSuffix, file); err != nil {
+				errs = append(errs, fmt.Errorf("shard %s: %w", shard.Name, err))
+			}
+		}
+		disabled = nil
+		return errors.Join(errs...)
+	}
+
+	for _, shard := range c.Shards {
+		file := filepath.Join(shard.Dir, ModOverrides)
+		if err := os.Rename(file, file+safeModeSuffix); errors.Is(err, os.ErrNotExist) {
+			// no mods enabled
+			continue
+		} else if err != nil {
+			return nil, errors.Join(fmt.Errorf("shard %s: %w", shard.Name, err), restore())
+		}
+		disabled = append(disabled, shard)
+
+		if err := os.WriteFile(file, []byte(emptyModOverrides), 0644); err != nil {
+			return nil, errors.Join(fmt.Errorf("shard %s: %w", shard.Name, err), restore())
+		}
+	}
+
+	return restore, nil
+}
+
+// StartSafeMode runs start with mods disabled, start should boot the cluster and return after the session ends,
+// the original modoverrides.lua are restored after start returns.
+func (c *Cluster) StartSafeMode(start func() error) error {
+	restore, err := c.DisableMods()
+	if err != nil {
+		return err
+	}
+	return errors.Join(start(), restore())
+}
+
+// RestoreMods puts back modoverrides.lua moved aside by DisableMods but never restored, such as the manager
+// was killed in safe mode, so the next start does not run with mods disabled silently. It returns the shards
+// restored, none if mods are not disabled.
+func (c *Cluster) RestoreMods() ([]string, error) {
+	var (
+		restored []string
+		errs     []error
+	)
+	for _, shard := range c.Shards {
+		file := filepath.Join(shard.Dir, ModOverrides)
+		if err := os.Rename(file+safeModeSuffix, file); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("shard %s: %w", shard.Name, err))
+			continue
+		}
+		restored = append(restored, shard.Name)
+
+		modOverrides, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shard %s: %w", shard.Name, err))
+			continue
+		}
+		shard.ModOverrides = string(modOverrides)
+	}
+	return restored, errors.Join(errs...)
+}
+
+// workshopModPattern matches the workshop mods listed in modoverrides.lua, like ["workshop-378160973"]
+var workshopModPattern = regexp.MustCompile(`\[\s*["']workshop-(\d+)["']\s*\]`)
+
+// CheckMods checks the workshop mods listed in modoverrides.lua of all shards are installed in one of modsDirs,
+// such as mods of the server installation and ugc_mods/content/322330, a mod is installed as a directory
+// named workshop-ID or ID. Mods not downloaded yet are reported, so check after the mods are updated.
+func (c *Cluster) CheckMods(modsDirs ...string) error {
+	var errs []error
+	for _, shard := range c.Shards {
+		var missing []string
+		for _, match := range workshopModPattern.FindAllStringSubmatch(shard.ModOverrides, -1) {
+			id := match[1]
+			if slices.Contains(missing, "workshop-"+id) || modInstalled(modsDirs, id) {
+				continue
+			}
+			missing = append(missing, "workshop-"+id)
+		}
+		if len(missing) > 0 {
+			errs = append(errs, errcode.Errorf(errcode.ModMissing, "shard %s: mods not installed: %s", shard.Name, strings.Join(missing, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// modInstalled returns whether the workshop mod of id is in one of modsDirs
+func modInstalled(modsDirs []string, id string) bool {
+	_, ok := modDir(modsDirs, id)
+	return ok
+}
+
+// modDir returns the directory of the workshop mod of id in the first of modsDirs it is installed in
+func modDir(modsDirs []string, id string) (string, bool) {
+	for _, dir := range modsDirs {
+		for _, name := range []string{"workshop-" + id, id} {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.IsDir() {
+				return filepath.Join(dir, name), true
+			}
+		}
+	}
+	return "", false
+}
+
+// workshopMods returns ids of the workshop mods listed in modoverrides.lua of all shards in order
+func (c *Cluster) workshopMods() []string {
+	var ids []string
+	for _, shard := range c.Shards {
+		for _, match := range workshopModPattern.FindAllStringSubmatch(shard.ModOverrides, -1) {
+			ids = append(ids, match[1])
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
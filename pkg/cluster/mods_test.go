@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleModOverrides = `return {
+  ["workshop-378160973"] = { enabled = true },
+}
+`
+
+func TestCluster_StartSafeMode(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cluster.ini":             sampleClusterINI,
+		"Master/server.ini":       "[SHARD]\nis_master = true\n",
+		"Master/modoverrides.lua": sampleModOverrides,
+		"Caves/server.ini":        "[SHARD]\nis_master = false\nname = Caves\n",
+	})
+
+	cluster, err := Load(dir)
+	require.NoError(t, err)
+
+	err = cluster.StartSafeMode(func() error {
+		content, err := os.ReadFile(filepath.Join(dir, "Master", ModOverrides))
+		require.NoError(t, err)
+		require.Equal(t, emptyModOverrides, string(content))
+
+		// caves has no mods, it is left alone
+		_, err = os.Stat(filepath.Join(dir, "Caves", ModOverrides))
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		// not restored yet
+		_, err = cluster.DisableMods()
+		require.ErrorContains(t, err, "already disabled")
+		return nil
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "Master", ModOverrides))
+	require.NoError(t, err)
+	require.Equal(t, sampleModOverrides, string(content))
+
+	_, err = os.Stat(filepath.Join(dir, "Master", ModOverrides+safeModeSuffix))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCluster_RestoreMods(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cluster.ini":             sampleClusterINI,
+		"Master/server.ini":       "[SHARD]\nis_master = true\n",
+		"Master/modoverrides.lua": sampleModOverrides,
+		"Caves/server.ini":        "[SHARD]\nis_master = false\nname = Caves\n",
+	})
+
+	cluster, err := Load(dir)
+	require.NoError(t, err)
+	restored, err := cluster.RestoreMods()
+	require.NoError(t, err)
+	require.Empty(t, restored)
+
+	// the manager exited in safe mode without restoring
+	_, err = cluster.DisableMods()
+	require.NoError(t, err)
+
+	cluster, err = Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, emptyModOverrides, cluster.Shard("Master").ModOverrides)
+	_, err = cluster.DisableMods()
+	require.Equal(t, errcode.ModsAlreadyDisabled, errcode.Of(err))
+
+	restored, err = cluster.RestoreMods()
+	require.NoError(t, err)
+	require.Equal(t, []string{"Master"}, restored)
+	require.Equal(t, sampleModOverrides, cluster.Shard("Master").ModOverrides)
+
+	content, err := os.ReadFile(filepath.Join(dir, "Master", ModOverrides))
+	require.NoError(t, err)
+	require.Equal(t, sampleModOverrides, string(content))
+	_, err = os.Stat(filepath.Join(dir, "Master", ModOverrides+safeModeSuffix))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCluster_CheckMods(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Cluster_1/cluster.ini":                          sampleClusterINI,
+		"Cluster_1/Master/server.ini":                    "[SHARD]\nis_master = true\n",
+		"Cluster_1/Master/modoverrides.lua":              sampleModOverrides,
+		"Cluster_1/Caves/server.ini":                     "[SHARD]\nis_master = false\nname = Caves\n",
+		"Cluster_1/Caves/modoverrides.lua":               "return {\n  [\"workshop-378160973\"] = { enabled = true },\n  ['workshop-1216718131'] = { enabled = true },\n}\n",
+		"mods/workshop-378160973/modinfo.lua":            "",
+		"ugc_mods/content/322330/1216718131/modinfo.lua": "",
+	})
+
+	cluster, err := Load(filepath.Join(dir, "Cluster_1"))
+	require.NoError(t, err)
+
+	mods, ugcMods := filepath.Join(dir, "mods"), filepath.Join(dir, "ugc_mods", "content", "322330")
+	require.NoError(t, cluster.CheckMods(mods, ugcMods))
+
+	err = cluster.CheckMods(mods)
+	t.Log(err)
+	require.Equal(t, errcode.ModMissing, errcode.Of(err))
+	require.ErrorContains(t, err, "shard Caves: mods not installed: workshop-1216718131")
+	require.NotContains(t, err.Error(), "Master")
+}
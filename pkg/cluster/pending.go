@@ -0,0 +1,154 @@
+package cluster
+
+import (
+	"path"
+	"slices"
+)
+
+// Change is a changed key of an ini file, or a changed file if Key is empty
+type Change struct {
+	// path of the file relative to the cluster directory, such as Master/server.ini
+	File    string
+	Section string
+	Key     string
+	// values before and after, empty if absent, they are not reported for the cluster token,
+	// and values of secret keys such as cluster_password are masked with SecretMask
+	Old, New string
+	Added    bool
+	Removed  bool
+}
+
+// SecretMask replaces the values of secret keys in changes
+const SecretMask = "***"
+
+// secretKeys are the ini keys whose values are never reported
+var secretKeys = []string{"cluster_password", "cluster_key", "server_password"}
+
+// maskSecret returns value masked if key is secret, empty values are kept to tell added and removed keys
+func maskSecret(key, value string) string {
+	if len(value) == 0 || !slices.Contains(secretKeys, key) {
+		return value
+	}
+	return SecretMask
+}
+
+// PendingChanges is the changes on disk not applied to the running shards yet
+type PendingChanges struct {
+	Changes []Change
+	// names of the shards which must restart to apply the changes
+	Restart []string
+}
+
+// Empty returns whether nothing changed
+func (p *PendingChanges) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// PendingChanges loads the cluster from disk again and compares it with c, which should be loaded when the shards started.
+// Changes of cluster.ini and cluster_token.txt require restarting all shards, changes of a shard require restarting the shard.
+func (c *Cluster) PendingChanges() (*PendingChanges, error) {
+	current, err := Load(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(c, current), nil
+}
+
+// Diff returns the changes from applied to current
+func Diff(applied, current *Cluster) *PendingChanges {
+	pending := &PendingChanges{}
+	restart := make(map[string]bool)
+
+	clusterChanges := diffINI(ClusterINI, applied.Config, current.Config)
+	if applied.Token != current.Token {
+		clusterChanges = append(clusterChanges, Change{
+			File:    ClusterToken,
+			Added:   len(applied.Token) == 0,
+			Removed: len(current.Token) == 0,
+		})
+	}
+	if len(clusterChanges) > 0 {
+		pending.Changes = append(pending.Changes, clusterChanges...)
+		for _, shard := range current.Shards {
+			restart[shard.Name] = true
+		}
+	}
+
+	for _, shard := range applied.Shards {
+		currentShard := current.Shard(shard.Name)
+		if currentShard == nil {
+			pending.Changes = append(pending.Changes, Change{File: path.Join(shard.Name, ServerINI), Removed: true})
+			// the running shard has to stop
+			restart[shard.Name] = true
+			continue
+		}
+
+		changes := diffINI(path.Join(shard.Name, ServerINI), shard.Config, currentShard.Config)
+		if shard.ModOverrides != currentShard.ModOverrides {
+			changes = append(changes, Change{
+				File:    path.Join(shard.Name, ModOverrides),
+				Old:     shard.ModOverrides,
+				New:     currentShard.ModOverrides,
+				Added:   len(shard.ModOverrides) == 0,
+				Removed: len(currentShard.ModOverrides) == 0,
+			})
+		}
+		if len(changes) > 0 {
+			pending.Changes = append(pending.Changes, changes...)
+			restart[shard.Name] = true
+		}
+	}
+
+	for _, shard := range current.Shards {
+		if applied.Shard(shard.Name) == nil {
+			pending.Changes = append(pending.Changes, Change{File: path.Join(shard.Name, ServerINI), Added: true})
+			restart[shard.Name] = true
+		}
+	}
+
+	// keep the order of shards, master goes first
+	for _, shard := range slices.Concat(current.Shards, applied.Shards) {
+		if restart[shard.Name] {
+			pending.Restart = append(pending.Restart, shard.Name)
+			delete(restart, shard.Name)
+		}
+	}
+	return pending
+}
+
+// diffINI returns the changed keys of file, in the order of old sections and keys, added ones at the end
+func diffINI(file string, old, new *INI) []Change {
+	var changes []Change
+	for _, section := range old.Sections() {
+		newSection := new.Section(section.Name)
+		for _, key := range section.Keys() {
+			oldValue, _ := section.Get(key)
+			var (
+				newValue string
+				ok       bool
+			)
+			if newSection != nil {
+				newValue, ok = newSection.Get(key)
+			}
+			if !ok {
+				changes = append(changes, Change{File: file, Section: section.Name, Key: key, Old: maskSecret(key, oldValue), Removed: true})
+			} else if oldValue != newValue {
+				changes = append(changes, Change{File: file, Section: section.Name, Key: key, Old: maskSecret(key, oldValue), New: maskSecret(key, newValue)})
+			}
+		}
+	}
+
+	for _, section := range new.Sections() {
+		oldSection := old.Section(section.Name)
+		for _, key := range section.Keys() {
+			if oldSection != nil {
+				if _, ok := oldSection.Get(key); ok {
+					continue
+				}
+			}
+			newValue, _ := section.Get(key)
+			changes = append(changes, Change{File: file, Section: section.Name, Key: key, New: maskSecret(key, newValue), Added: true})
+		}
+	}
+	return changes
+}
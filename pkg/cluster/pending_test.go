@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_PendingChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cluster.ini":             sampleClusterINI,
+		"Master/server.ini":       "[SHARD]\nis_master = true\n\n[NETWORK]\nserver_port = 10999\n",
+		"Master/modoverrides.lua": "return {}",
+		"Caves/server.ini":        "[SHARD]\nis_master = false\nname = Caves\n",
+	})
+
+	applied, err := Load(dir)
+	require.NoError(t, err)
+
+	pending, err := applied.PendingChanges()
+	require.NoError(t, err)
+	require.True(t, pending.Empty())
+	require.Empty(t, pending.Restart)
+
+	// shard changes only restart the shard
+	writeFiles(t, dir, map[string]string{
+		"Caves/server.ini":       "[SHARD]\nis_master = false\nname = Caves\nid = 2\n",
+		"Caves/modoverrides.lua": "return {}",
+	})
+	pending, err = applied.PendingChanges()
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{File: "Caves/server.ini", Section: "SHARD", Key: "id", New: "2", Added: true},
+		{File: "Caves/modoverrides.lua", New: "return {}", Added: true},
+	}, pending.Changes)
+	require.Equal(t, []string{"Caves"}, pending.Restart)
+
+	// cluster changes restart all shards
+	cluster := applied.Config
+	writeFiles(t, dir, map[string]string{"Caves/server.ini": "[SHARD]\nis_master = false\nname = Caves\n"})
+	require.NoError(t, os.Remove(filepath.Join(dir, "Caves", ModOverrides)))
+	cluster.Set("GAMEPLAY", "max_players", "12")
+	require.NoError(t, cluster.WriteFile(filepath.Join(dir, ClusterINI)))
+	cluster.Set("GAMEPLAY", "max_players", "6")
+	writeFiles(t, dir, map[string]string{"cluster_token.txt": "pds-g^KU_abc123XY^Zm9vYmFyCg==\n"})
+
+	pending, err = applied.PendingChanges()
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{File: "cluster.ini", Section: "GAMEPLAY", Key: "max_players", Old: "6", New: "12"},
+		{File: "cluster_token.txt", Added: true},
+	}, pending.Changes)
+	require.Equal(t, []string{"Master", "Caves"}, pending.Restart)
+}
+
+func TestDiff_Secrets(t *testing.T) {
+	applied, current := &INI{}, &INI{}
+	applied.Set("NETWORK", "cluster_password", "old secret")
+	current.Set("NETWORK", "cluster_password", "new secret")
+	applied.Set("SHARD", "cluster_key", "key")
+	current.Set("NETWORK", "cluster_name", "DST")
+	applied.Set("NETWORK", "server_password", "")
+	current.Set("NETWORK", "server_password", "added secret")
+
+	changes := diffINI(ClusterINI, applied, current)
+	t.Log(changes)
+	require.Equal(t, []Change{
+		{File: "cluster.ini", Section: "NETWORK", Key: "cluster_password", Old: SecretMask, New: SecretMask},
+		{File: "cluster.ini", Section: "NETWORK", Key: "server_password", Old: "", New: SecretMask},
+		{File: "cluster.ini", Section: "SHARD", Key: "cluster_key", Old: SecretMask, Removed: true},
+		{File: "cluster.ini", Section: "NETWORK", Key: "cluster_name", New: "DST", Added: true},
+	}, changes)
+}
+
+func TestDiff_Shards(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cluster.ini":       sampleClusterINI,
+		"Master/server.ini": "[SHARD]\nis_master = true\n",
+		"Caves/server.ini":  "[SHARD]\nis_master = false\nname = Caves\n",
+	})
+	applied, err := Load(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "Caves")))
+	writeFiles(t, dir, map[string]string{"Forest/server.ini": "[SHARD]\nis_master = false\nname = Forest\n"})
+	current, err := Load(dir)
+	require.NoError(t, err)
+
+	pending := Diff(applied, current)
+	require.Equal(t, []Change{
+		{File: "Caves/server.ini", Removed: true},
+		{File: "Forest/server.ini", Added: true},
+	}, pending.Changes)
+	require.Equal(t, []string{"Forest", "Caves"}, pending.Restart)
+}
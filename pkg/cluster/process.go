@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"context"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// ServerProcessName is the executable name prefix of the dedicated server
+const ServerProcessName = "dontstarve_dedicated_server"
+
+// ServerProcess is a running dedicated server process found on the host
+type ServerProcess struct {
+	PID int32
+	// arguments of the server, with defaults filled if absent
+	Cluster               string
+	Shard                 string
+	PersistentStorageRoot string
+	ConfDir               string
+}
+
+// ClusterDir returns the cluster directory the process runs with
+func (p ServerProcess) ClusterDir() string {
+	return filepath.Join(p.PersistentStorageRoot, p.ConfDir, p.Cluster)
+}
+
+// FindProcesses returns all running dedicated server processes on the host
+func FindProcesses(ctx context.Context) ([]ServerProcess, error) {
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var servers []ServerProcess
+	for _, p := range processes {
+		cmdline, err := p.CmdlineSliceWithContext(ctx)
+		// process may exit or be inaccessible, just skip it
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+		if !strings.HasPrefix(filepath.Base(cmdline[0]), ServerProcessName) {
+			continue
+		}
+		servers = append(servers, parseServerArgs(p.Pid, cmdline[1:], processHome(ctx, p)))
+	}
+	return servers, nil
+}
+
+// processHome returns the home directory of the process, by HOME of its environment, or the home of the
+// user running it if the environment is not readable, empty if neither is known
+func processHome(ctx context.Context, p *process.Process) string {
+	if environ, err := p.EnvironWithContext(ctx); err == nil {
+		for _, env := range environ {
+			if home, ok := strings.CutPrefix(env, "HOME="); ok && len(home) > 0 {
+				return home
+			}
+		}
+	}
+	if username, err := p.UsernameWithContext(ctx); err == nil {
+		if u, err := user.Lookup(username); err == nil {
+			return u.HomeDir
+		}
+	}
+	return ""
+}
+
+// parseServerArgs parses the arguments of dedicated server, fills defaults which the server uses,
+// the default persistent storage root is .klei in home of the process
+func parseServerArgs(pid int32, args []string, home string) ServerProcess {
+	server := ServerProcess{
+		PID:     pid,
+		Cluster: "Cluster_1",
+		Shard:   "Master",
+		ConfDir: "DoNotStarveTogether",
+	}
+	if len(home) > 0 {
+		server.PersistentStorageRoot = filepath.Join(home, ".klei")
+	}
+
+	for i := 0; i+1 < len(args); i++ {
+		value := args[i+1]
+		switch args[i] {
+		case "-cluster":
+			server.Cluster = value
+		case "-shard":
+			server.Shard = value
+		case "-persistent_storage_root":
+			server.PersistentStorageRoot = value
+		case "-conf_dir":
+			server.ConfDir = value
+		default:
+			continue
+		}
+		i++
+	}
+	return server
+}
+
+// Adopt finds running server processes and sets PID of the shards they run, it returns the adopted shards
+func Adopt(ctx context.Context, clusters []*Cluster) ([]*Shard, error) {
+	servers, err := FindProcesses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var adopted []*Shard
+	for _, server := range servers {
+		serverDir := filepath.Clean(server.ClusterDir())
+		for _, cluster := range clusters {
+			if filepath.Clean(cluster.Dir) != serverDir {
+				continue
+			}
+			if shard := cluster.Shard(server.Shard); shard != nil {
+				shard.PID = server.PID
+				adopted = append(adopted, shard)
+			}
+		}
+	}
+	return adopted, nil
+}
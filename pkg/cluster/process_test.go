@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerArgs(t *testing.T) {
+	server := parseServerArgs(1, []string{"-console", "-cluster", "MyDediServer", "-shard", "Caves", "-monitor_parent_process", "12", "-conf_dir", "DST"}, "/home/steam")
+	require.Equal(t, "MyDediServer", server.Cluster)
+	require.Equal(t, "Caves", server.Shard)
+	require.Equal(t, "DST", server.ConfDir)
+	// the home of the server process, not of the manager
+	require.Equal(t, "/home/steam/.klei", server.PersistentStorageRoot)
+	require.Equal(t, "/home/steam/.klei/DST/MyDediServer", server.ClusterDir())
+
+	server = parseServerArgs(1, []string{"-persistent_storage_root", "/srv/dst"}, "/home/steam")
+	require.Equal(t, "/srv/dst", server.PersistentStorageRoot)
+
+	server = parseServerArgs(1, nil, "")
+	require.Equal(t, "Cluster_1", server.Cluster)
+	require.Equal(t, "Master", server.Shard)
+}
+
+func TestAdopt(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"DST/MyDediServer/cluster.ini":       sampleClusterINI,
+		"DST/MyDediServer/Master/server.ini": "[SHARD]\nis_master = true\n",
+		"DST/MyDediServer/Caves/server.ini":  "[SHARD]\nis_master = false\n",
+		"DST/OtherServer/cluster.ini":        sampleClusterINI,
+		"DST/OtherServer/Master/server.ini":  "[SHARD]\nis_master = true\n",
+	})
+
+	// a copy of bash named as the dedicated server plays the running caves shard
+	bash, err := os.ReadFile("/bin/bash")
+	require.NoError(t, err)
+	server := filepath.Join(t.TempDir(), "dontstarve_dedicated_server_nullrenderer")
+	require.NoError(t, os.WriteFile(server, bash, 0755))
+
+	p, err := proc.NewProc(context.Background(),
+		proc.WithCommand(server, "-c", "sleep 10; exit 0", "-console",
+			"-cluster", "MyDediServer", "-shard", "Caves",
+			"-persistent_storage_root", root, "-conf_dir", "DST"),
+	)
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+	defer func() {
+		t.Log(p.Kill())
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	clusters, err := Discover(filepath.Join(root, "DST"))
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+
+	adopted, err := Adopt(context.Background(), clusters)
+	require.NoError(t, err)
+	require.Len(t, adopted, 1)
+	require.Equal(t, "Caves", adopted[0].Name)
+	require.Equal(t, int32(p.PID()), clusters[0].Shard("Caves").PID)
+	require.Zero(t, clusters[0].Shard("Master").PID)
+}
@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+	"golang.org/x/sync/errgroup"
+)
+
+// RollingRestart restarts many clusters a few at a time instead of all at once, such as after a host update
+type RollingRestart struct {
+	// max clusters restarting at the same time, default 1
+	Concurrency int
+	// wait after a cluster restarted before its slot is given to the next cluster
+	Delay time.Duration
+	// called before Restart, such as announcing to players and saving the world, error skips the cluster
+	Drain func(ctx context.Context, cluster *Cluster) error
+	// restarts the shards of cluster and returns once they are up, required
+	Restart func(ctx context.Context, cluster *Cluster) error
+	// do not restart the remaining clusters after the first failure
+	StopOnError bool
+	// source of time for Delay, default proc.RealClock
+	Clock proc.Clock
+}
+
+// Run restarts clusters in order, returns the failures joined, or the first failure if StopOnError
+func (r RollingRestart) Run(ctx context.Context, clusters []*Cluster) error {
+	if r.Restart == nil {
+		return errors.New("rolling restart: Restart is required")
+	}
+
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	clock := r.Clock
+	if clock == nil {
+		clock = proc.RealClock{}
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(concurrency)
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	for i, cluster := range clusters {
+		if groupCtx.Err() != nil {
+			break
+		}
+
+		last := i == len(clusters)-1
+		group.Go(func() error {
+			// the slot may be released by a failure which stops the restart
+			if groupCtx.Err() != nil {
+				return nil
+			}
+			if err := r.restart(groupCtx, cluster); err != nil {
+				err = fmt.Errorf("cluster %s: %w", cluster.Name, err)
+				if r.StopOnError {
+					return err
+				}
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+
+			if r.Delay > 0 && !last {
+				select {
+				case <-groupCtx.Done():
+				case <-clock.After(r.Delay):
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	return errors.Join(errs...)
+}
+
+func (r RollingRestart) restart(ctx context.Context, cluster *Cluster) error {
+	if r.Drain != nil {
+		if err := r.Drain(ctx, cluster); err != nil {
+			return fmt.Errorf("drain: %w", err)
+		}
+	}
+	return r.Restart(ctx, cluster)
+}
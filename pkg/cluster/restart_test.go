@@ -0,0 +1,124 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc/proctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollingRestart(t *testing.T) {
+	clusters := []*Cluster{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+
+	var (
+		mu       sync.Mutex
+		drained  []string
+		running  atomic.Int32
+		peak     atomic.Int32
+		restarts atomic.Int32
+	)
+	rolling := RollingRestart{
+		Concurrency: 2,
+		Delay:       10 * time.Millisecond,
+		Drain: func(ctx context.Context, cluster *Cluster) error {
+			mu.Lock()
+			drained = append(drained, cluster.Name)
+			mu.Unlock()
+			if cluster.Name == "c" {
+				return errors.New("players still online")
+			}
+			return nil
+		},
+		Restart: func(ctx context.Context, cluster *Cluster) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			restarts.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		},
+	}
+
+	err := rolling.Run(context.Background(), clusters)
+	require.ErrorContains(t, err, "cluster c: drain: players still online")
+	require.ElementsMatch(t, []string{"a", "b", "c", "d", "e"}, drained)
+	require.EqualValues(t, 4, restarts.Load())
+	require.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestRollingRestart_Delay(t *testing.T) {
+	clusters := []*Cluster{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	clock := proctest.NewFakeClock(time.Now())
+
+	var (
+		mu        sync.Mutex
+		restarted []string
+	)
+	restartedList := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(restarted)
+	}
+	rolling := RollingRestart{
+		Delay: time.Hour,
+		Clock: clock,
+		Restart: func(ctx context.Context, cluster *Cluster) error {
+			mu.Lock()
+			restarted = append(restarted, cluster.Name)
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- rolling.Run(context.Background(), clusters)
+	}()
+
+	// the next cluster waits for the delay after the previous one restarted
+	clock.BlockUntil(1)
+	require.Equal(t, []string{"a"}, restartedList())
+	clock.Advance(time.Hour)
+
+	clock.BlockUntil(1)
+	require.Equal(t, []string{"a", "b"}, restartedList())
+	clock.Advance(time.Hour)
+
+	// no delay after the last cluster
+	require.NoError(t, <-errCh)
+	require.Equal(t, []string{"a", "b", "c"}, restartedList())
+	require.Zero(t, clock.Timers())
+}
+
+func TestRollingRestart_StopOnError(t *testing.T) {
+	clusters := []*Cluster{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+
+	var restarted []string
+	rolling := RollingRestart{
+		StopOnError: true,
+		Restart: func(ctx context.Context, cluster *Cluster) error {
+			restarted = append(restarted, cluster.Name)
+			if cluster.Name == "b" {
+				return errors.New("shard did not come up")
+			}
+			return nil
+		},
+	}
+
+	err := rolling.Run(context.Background(), clusters)
+	require.ErrorContains(t, err, "cluster b: shard did not come up")
+	require.Equal(t, []string{"a", "b"}, restarted)
+
+	require.Error(t, RollingRestart{}.Run(context.Background(), clusters))
+}
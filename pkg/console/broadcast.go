@@ -0,0 +1,69 @@
+package console
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ShardResult is the result of a broadcast on a shard
+type ShardResult struct {
+	Shard string
+	// result of query, empty for commands
+	Result string
+	Err    error
+}
+
+// Broadcast sends cmd to the consoles of all shards concurrently, consoles are keyed by shard name,
+// it returns results sorted by shard name and the errors of all failed shards joined.
+func Broadcast(consoles map[string]*Console, cmd string) ([]ShardResult, error) {
+	return broadcast(consoles, func(console *Console) (string, error) {
+		return "", console.Exec(cmd)
+	})
+}
+
+// BroadcastSnippet renders the named snippet with params and broadcasts it, see Broadcast
+func BroadcastSnippet(consoles map[string]*Console, name string, params Params) ([]ShardResult, error) {
+	return broadcast(consoles, func(console *Console) (string, error) {
+		return "", console.ExecSnippet(name, params)
+	})
+}
+
+// BroadcastQuery runs the query on the consoles of all shards concurrently, see Broadcast
+func BroadcastQuery(ctx context.Context, consoles map[string]*Console, lua string) ([]ShardResult, error) {
+	return broadcast(consoles, func(console *Console) (string, error) {
+		return console.Query(ctx, lua)
+	})
+}
+
+func broadcast(consoles map[string]*Console, fn func(console *Console) (string, error)) ([]ShardResult, error) {
+	results := make([]ShardResult, 0, len(consoles))
+	for shard := range consoles {
+		results = append(results, ShardResult{Shard: shard})
+	}
+	slices.SortFunc(results, func(a, b ShardResult) int {
+		return cmp.Compare(a.Shard, b.Shard)
+	})
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := &results[i]
+			result.Result, result.Err = fn(consoles[result.Shard])
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("shard %s: %w", result.Shard, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
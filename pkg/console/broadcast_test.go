@@ -0,0 +1,42 @@
+package console
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcastQuery(t *testing.T) {
+	master, masterConsole := newFakeConsole(t, "master")
+	caves, cavesConsole := newFakeConsole(t, "caves")
+	defer master.Terminate()
+	defer caves.Terminate()
+
+	consoles := map[string]*Console{
+		"Master": masterConsole,
+		"Caves":  cavesConsole,
+		"Forest": NewConsole(nil),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results, err := BroadcastQuery(ctx, consoles, "return TheShard:GetShardId()")
+	require.ErrorIs(t, err, ErrNoStdout)
+	require.ErrorContains(t, err, "shard Forest")
+	t.Log(err)
+
+	require.Len(t, results, 3)
+	require.Equal(t, ShardResult{Shard: "Caves", Result: "caves"}, results[0])
+	require.Equal(t, "Forest", results[1].Shard)
+	require.ErrorIs(t, results[1].Err, ErrNoStdout)
+	require.Equal(t, ShardResult{Shard: "Master", Result: "master"}, results[2])
+
+	results, err = Broadcast(consoles, "c_save()")
+	require.ErrorIs(t, err, ErrClosed)
+	require.NoError(t, results[0].Err)
+	require.ErrorIs(t, results[1].Err, ErrClosed)
+	require.NoError(t, results[2].Err)
+}
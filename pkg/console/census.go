@@ -0,0 +1,109 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Census is the entity counts by prefab of the world at a moment
+type Census struct {
+	Time   time.Time      `json:"time"`
+	Counts map[string]int `json:"counts"`
+}
+
+// PrefabCount is the number of entities of a prefab
+type PrefabCount struct {
+	Prefab string
+	Count  int
+}
+
+// Total returns the number of all entities
+func (c Census) Total() int {
+	var total int
+	for _, count := range c.Counts {
+		total += count
+	}
+	return total
+}
+
+// Top returns n prefabs with the most entities in descending order, none if n is not positive
+func (c Census) Top(n int) []PrefabCount {
+	var counts []PrefabCount
+	for prefab, count := range c.Counts {
+		counts = append(counts, PrefabCount{Prefab: prefab, Count: count})
+	}
+	slices.SortFunc(counts, func(a, b PrefabCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Prefab, b.Prefab)
+	})
+	return counts[:max(min(n, len(counts)), 0)]
+}
+
+// Census counts all entities in the world by prefab
+func (c *Console) Census(ctx context.Context) (Census, error) {
+	result, err := c.QuerySnippet(ctx, "entity_census", nil)
+	if err != nil {
+		return Census{}, err
+	}
+
+	census := Census{Time: c.options.Clock.Now(), Counts: make(map[string]int)}
+	for _, pair := range strings.Split(result, ",") {
+		if len(pair) == 0 {
+			continue
+		}
+		prefab, count, ok := strings.Cut(pair, "=")
+		if !ok {
+			return Census{}, fmt.Errorf("census: unexpected result %q", pair)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return Census{}, fmt.Errorf("census: unexpected result %q", pair)
+		}
+		census.Counts[prefab] = n
+	}
+	return census, nil
+}
+
+// RunCensus takes a census every interval and adds it into history until ctx is done,
+// a census that does not finish within interval is skipped.
+func (c *Console) RunCensus(ctx context.Context, interval time.Duration, history *CensusHistory) error {
+	return runPeriodic(ctx, c.options.Clock, "census", interval, c.Census, &history.History)
+}
+
+// NewCensusHistory return a history keeps at most size census
+func NewCensusHistory(size int) *CensusHistory {
+	return &CensusHistory{History: History[Census]{size: max(size, 1)}}
+}
+
+// CensusHistory keeps the recent census, see History
+type CensusHistory struct {
+	History[Census]
+}
+
+// Trend returns the count changes of each prefab between the oldest and the latest census
+func (h *CensusHistory) Trend() map[string]int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	trend := make(map[string]int)
+	if len(h.records) == 0 {
+		return trend
+	}
+
+	first, last := h.records[0], h.records[len(h.records)-1]
+	for prefab, count := range last.Counts {
+		trend[prefab] = count - first.Counts[prefab]
+	}
+	for prefab, count := range first.Counts {
+		if _, ok := last.Counts[prefab]; !ok {
+			trend[prefab] = -count
+		}
+	}
+	return trend
+}
@@ -0,0 +1,58 @@
+package console
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc/proctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsole_Census(t *testing.T) {
+	start := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	clock := proctest.NewFakeClock(start)
+	p, console := newFakeConsole(t, "rocks=120,spiderden=3,flint=120", WithClock(clock))
+	defer func() {
+		t.Log(p.Terminate())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	census, err := console.Census(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 243, census.Total())
+	require.Equal(t, []PrefabCount{{"flint", 120}, {"rocks", 120}}, census.Top(2))
+	require.Empty(t, census.Top(-1))
+	require.Error(t, console.RunCensus(ctx, 0, NewCensusHistory(1)))
+
+	history := NewCensusHistory(2)
+	runCtx, runCancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- console.RunCensus(runCtx, time.Hour, history)
+	}()
+	advanceRuns(clock, time.Hour, 3)
+	runCancel()
+	require.NoError(t, <-errCh)
+
+	records := history.List()
+	require.Len(t, records, 2)
+	require.Equal(t, start.Add(3*time.Hour), records[1].Time)
+	t.Logf("census history: %+v", records)
+}
+
+func TestCensusHistory_Trend(t *testing.T) {
+	history := NewCensusHistory(3)
+	history.Add(Census{Counts: map[string]int{"rocks": 10, "log": 5}})
+	history.Add(Census{Counts: map[string]int{"rocks": 12}})
+	history.Add(Census{Counts: map[string]int{"rocks": 20, "silk": 3}})
+	history.Add(Census{Counts: map[string]int{"rocks": 30, "silk": 4}})
+
+	require.Len(t, history.List(), 3)
+	latest, ok := history.Latest()
+	require.True(t, ok)
+	require.Equal(t, 34, latest.Total())
+	require.Equal(t, map[string]int{"rocks": 18, "silk": 4}, history.Trend())
+}
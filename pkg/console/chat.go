@@ -0,0 +1,171 @@
+package console
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+)
+
+// chatPattern matches chat lines of server log, like [00:05:12]: [Say] (KU_abcd1234) Wilson: hello
+var chatPattern = regexp.MustCompile(`^(?:\[\d+:\d{2}:\d{2}\]: )?\[(Say|Whisper)\] \((\S+)\) (.+?): (.*)$`)
+
+// ChatMessage is a chat message of a player read from server log
+type ChatMessage struct {
+	Whisper bool
+	UserID  string
+	Name    string
+	Text    string
+}
+
+// ParseChat parses a chat line of server log, returns false if the line is not chat
+func ParseChat(line string) (ChatMessage, bool) {
+	match := chatPattern.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if match == nil {
+		return ChatMessage{}, false
+	}
+	return ChatMessage{Whisper: match[1] == "Whisper", UserID: match[2], Name: match[3], Text: match[4]}, true
+}
+
+// KeywordWatch takes actions on chat messages containing keywords, keywords are matched case-insensitively as whole words
+type KeywordWatch struct {
+	Keywords []string
+	// announced to warn the player on every hit, %s is replaced with the player name, empty disables warning
+	WarnMessage string
+	// kick the player after the number of hits, 0 disables kicking
+	KickAfter int
+	// called on every hit, such as flagging the message to moderators
+	OnHit func(hit KeywordHit)
+}
+
+// KeywordHit is a chat message matched by KeywordWatch
+type KeywordHit struct {
+	Message ChatMessage
+	Keyword string
+	// number of hits of the player, including this one
+	Hits int
+	// the player is kicked by this hit
+	Kicked bool
+}
+
+// WatchKeywords reads server log lines from stream and takes actions of watch, until the stream closed or ctx done.
+// The stream must be a dedicated stdout pipe, not the one used for queries of the console.
+func (c *Console) WatchKeywords(ctx context.Context, stream *proc.Stream, watch KeywordWatch) error {
+	if len(watch.Keywords) == 0 {
+		return nil
+	}
+
+	pattern, err := keywordPattern(watch.Keywords)
+	if err != nil {
+		return err
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for {
+			line, ok := stream.Recv()
+			if !ok {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case lines <- string(line):
+			}
+		}
+	}()
+
+	// hits by user id
+	hits := make(map[string]int)
+	for {
+		var line string
+		select {
+		case <-ctx.Done():
+			return nil
+		case l, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			line = l
+		}
+
+		message, ok := ParseChat(line)
+		if !ok {
+			continue
+		}
+		keyword := findKeyword(pattern, message.Text)
+		if len(keyword) == 0 {
+			continue
+		}
+
+		hits[message.UserID]++
+		hit := KeywordHit{Message: message, Keyword: keyword, Hits: hits[message.UserID]}
+		if watch.KickAfter > 0 && hit.Hits >= watch.KickAfter {
+			hit.Kicked = true
+			delete(hits, message.UserID)
+		}
+
+		if len(watch.WarnMessage) > 0 && !hit.Kicked {
+			if err := c.ExecSnippet("announce", Params{"message": strings.ReplaceAll(watch.WarnMessage, "%s", message.Name)}); err != nil {
+				return err
+			}
+		}
+		if hit.Kicked {
+			if err := c.ExecSnippet("kick", Params{"userid": message.UserID}); err != nil {
+				return err
+			}
+		}
+		if watch.OnHit != nil {
+			watch.OnHit(hit)
+		}
+	}
+}
+
+// wordBoundary matches a char not in a word, with the start or end of text it is \b for all scripts
+const wordBoundary = `[^\p{L}\p{N}_]`
+
+// keywordPattern matches any of keywords case-insensitively as whole words, each keyword is a group.
+// A keyword starting or ending with a CJK char needs no boundary on that side, because CJK text has no spaces between words.
+func keywordPattern(keywords []string) (*regexp.Regexp, error) {
+	alternatives := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		first, _ := utf8.DecodeRuneInString(keyword)
+		last, _ := utf8.DecodeLastRuneInString(keyword)
+
+		alternative := "(" + regexp.QuoteMeta(keyword) + ")"
+		if isWordRune(first) {
+			alternative = "(?:^|" + wordBoundary + ")" + alternative
+		}
+		if isWordRune(last) {
+			alternative += "(?:$|" + wordBoundary + ")"
+		}
+		alternatives = append(alternatives, alternative)
+	}
+	return regexp.Compile(`(?i)` + strings.Join(alternatives, "|"))
+}
+
+// findKeyword returns the keyword matched by pattern of keywordPattern in text, or empty
+func findKeyword(pattern *regexp.Regexp, text string) string {
+	groups := pattern.FindStringSubmatch(text)
+	if groups == nil {
+		return ""
+	}
+	for _, group := range groups[1:] {
+		if len(group) > 0 {
+			return group
+		}
+	}
+	return ""
+}
+
+// isWordRune returns whether r is a char of words separated by spaces
+func isWordRune(r rune) bool {
+	if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+		return false
+	}
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
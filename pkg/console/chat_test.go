@@ -0,0 +1,94 @@
+package console
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChat(t *testing.T) {
+	message, ok := ParseChat("[00:05:12]: [Say] (KU_abcd1234) Wilson: hello: world\r\n")
+	require.True(t, ok)
+	require.Equal(t, ChatMessage{UserID: "KU_abcd1234", Name: "Wilson", Text: "hello: world"}, message)
+
+	message, ok = ParseChat("[Whisper] (KU_x) Willow: psst")
+	require.True(t, ok)
+	require.True(t, message.Whisper)
+
+	_, ok = ParseChat("[00:05:12]: [Announcement] Wilson joined")
+	require.False(t, ok)
+}
+
+func TestConsole_WatchKeywords(t *testing.T) {
+	stdin := proc.MakeChannel[[]byte](8)
+	console := NewConsole(stdin)
+
+	stdout := proc.MakeChannel[[]byte](0)
+	go func() {
+		for _, line := range []string{
+			"[00:00:01]: [Say] (KU_a) Griefer: BADWORD here",
+			"[00:00:02]: [Say] (KU_b) Wilson: badwords are not words",
+			"[00:00:03]: [Announcement] badword",
+			"[00:00:04]: [Say] (KU_a) Griefer: badword again",
+		} {
+			stdout.Send([]byte(line))
+		}
+		stdout.Close()
+	}()
+
+	var hits []KeywordHit
+	err := console.WatchKeywords(context.Background(), stdout, KeywordWatch{
+		Keywords:    []string{"badword"},
+		WarnMessage: "%s, watch your language",
+		KickAfter:   2,
+		OnHit: func(hit KeywordHit) {
+			hits = append(hits, hit)
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, hits, 2)
+	require.Equal(t, "BADWORD", hits[0].Keyword)
+	require.Equal(t, 1, hits[0].Hits)
+	require.False(t, hits[0].Kicked)
+	require.Equal(t, 2, hits[1].Hits)
+	require.True(t, hits[1].Kicked)
+
+	var cmds []string
+	for {
+		cmd, ok := stdin.TryRecv()
+		if !ok {
+			break
+		}
+		cmds = append(cmds, string(cmd))
+	}
+	require.Equal(t, []string{
+		"c_announce(\"Griefer, watch your language\")\n",
+		"TheNet:Kick(\"KU_a\")\n",
+	}, cmds)
+}
+
+func TestKeywordPattern(t *testing.T) {
+	pattern, err := keywordPattern([]string{"badword", "笨蛋", "ばか", "été"})
+	require.NoError(t, err)
+
+	samples := []struct {
+		text   string
+		expect string
+	}{
+		{"BADWORD here", "BADWORD"},
+		{"badwords are not words", ""},
+		{"mybadword", ""},
+		{"(badword)", "badword"},
+		{"你是笨蛋吗", "笨蛋"},
+		{"お前はばかだ", "ばか"},
+		{"Été chaud", "Été"},
+		{"étéx", ""},
+		{"bonjour", ""},
+	}
+	for _, sample := range samples {
+		require.Equal(t, sample.expect, findKeyword(pattern, sample.text), sample.text)
+	}
+}
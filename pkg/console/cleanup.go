@@ -0,0 +1,75 @@
+package console
+
+import (
+	"context"
+	"fmt"
+)
+
+// CleanupRule selects a class of lag causing entities
+type CleanupRule struct {
+	// prefab name of the entities
+	Prefab string
+	// only match entities lying on the ground, which are not held by any inventory or container
+	OnGround bool
+	// only match entities lying on the ground for at least MinDays game days, 0 matches all. Entities have no age
+	// in game, so days are counted since a cleanup first saw them on the ground, run cleanup periodically, dry run
+	// is enough, to track them. Tracking starts over after the shard restarted or the entity was picked up.
+	MinDays float64
+	// number of matched entities to keep, the rest are removed
+	Keep int
+}
+
+// CleanupResult is the outcome of a CleanupRule
+type CleanupResult struct {
+	Rule CleanupRule
+	// number of matched entities
+	Count int
+	// number of removed entities, or would be removed in dry run
+	Removed int
+}
+
+// DefaultCleanupRules are the items that usually pile up on the ground of long-running worlds
+var DefaultCleanupRules = []CleanupRule{
+	{Prefab: "spoiled_food", OnGround: true},
+	{Prefab: "rottenegg", OnGround: true},
+	// drops of spiders and spider dens left around the dens
+	{Prefab: "monstermeat", OnGround: true, MinDays: 2, Keep: 40},
+	{Prefab: "silk", OnGround: true, MinDays: 2, Keep: 40},
+	{Prefab: "spidergland", OnGround: true, MinDays: 2, Keep: 40},
+	{Prefab: "spidereggsack", OnGround: true, MinDays: 5, Keep: 10},
+	{Prefab: "stinger", OnGround: true, MinDays: 2, Keep: 40},
+	{Prefab: "houndstooth", OnGround: true, MinDays: 2, Keep: 40},
+	// resources flooding the ground, only the ones left there for long
+	{Prefab: "rocks", OnGround: true, MinDays: 10, Keep: 200},
+	{Prefab: "flint", OnGround: true, MinDays: 10, Keep: 200},
+	{Prefab: "log", OnGround: true, MinDays: 10, Keep: 200},
+	{Prefab: "twigs", OnGround: true, MinDays: 10, Keep: 200},
+	{Prefab: "cutgrass", OnGround: true, MinDays: 10, Keep: 200},
+}
+
+// Cleanup counts the entities matched by each rule and removes the ones beyond Keep,
+// nothing is removed if dryRun is true, so it is suggested to dry run first and check the counts.
+func (c *Console) Cleanup(ctx context.Context, rules []CleanupRule, dryRun bool) ([]CleanupResult, error) {
+	var results []CleanupResult
+
+	for _, rule := range rules {
+		result, err := c.QuerySnippet(ctx, "cleanup_entities", Params{
+			"prefab":    rule.Prefab,
+			"on_ground": rule.OnGround,
+			"min_days":  max(rule.MinDays, 0),
+			"keep":      max(rule.Keep, 0),
+			"remove":    !dryRun,
+		})
+		if err != nil {
+			return results, fmt.Errorf("cleanup %s: %w", rule.Prefab, err)
+		}
+
+		cleanupResult := CleanupResult{Rule: rule}
+		if _, err := fmt.Sscanf(result, "%d %d", &cleanupResult.Count, &cleanupResult.Removed); err != nil {
+			return results, fmt.Errorf("cleanup %s: unexpected result %q", rule.Prefab, result)
+		}
+		results = append(results, cleanupResult)
+	}
+
+	return results, nil
+}
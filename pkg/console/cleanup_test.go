@@ -0,0 +1,34 @@
+package console
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsole_Cleanup(t *testing.T) {
+	p, console := newFakeConsole(t, "12 2")
+	defer func() {
+		t.Log(p.Terminate())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results, err := console.Cleanup(ctx, DefaultCleanupRules[:2], true)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		t.Logf("%s: count %d removed %d", result.Rule.Prefab, result.Count, result.Removed)
+		require.Equal(t, 12, result.Count)
+		require.Equal(t, 2, result.Removed)
+	}
+
+	cmd, err := console.Library().Render("cleanup_entities", Params{"prefab": "rocks", "on_ground": true, "min_days": 2.5, "keep": 10, "remove": false})
+	require.NoError(t, err)
+	require.Contains(t, cmd, `ent.prefab == "rocks"`)
+	require.Contains(t, cmd, `now - seen[ent] >= 2.5`)
+	require.Contains(t, cmd, `if false then ent:Remove() end`)
+}
@@ -0,0 +1,106 @@
+package console
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+	"github.com/dstgo/dontstarve/pkg/proc"
+)
+
+// ErrClosed returned when sending command into a closed console
+var ErrClosed = errcode.New(errcode.ConsoleClosed, "console closed")
+
+// NewConsole return a console which sends lua commands into stdin of the server process
+func NewConsole(stdin *proc.Stream, options ...Option) *Console {
+	var opts Options
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	if opts.Library == nil {
+		opts.Library = NewLibrary()
+	}
+	if opts.Clock == nil {
+		opts.Clock = proc.RealClock{}
+	}
+
+	console := &Console{
+		stdin:    stdin,
+		options:  opts,
+		queryTag: "dstq" + strconv.FormatInt(time.Now().UnixNano()%(1<<32), 36),
+		queries:  make(map[string]chan string),
+	}
+
+	if opts.Stdout != nil {
+		go console.readStdout()
+	}
+
+	return console
+}
+
+// Console represent the lua console of a running shard
+type Console struct {
+	stdin *proc.Stream
+
+	// pending queries waiting for results from stdout
+	queryID     atomic.Uint64
+	queryTag    string
+	queryMu     sync.Mutex
+	queries     map[string]chan string
+	queryClosed bool
+
+	// commands recorded since StartRecording, nil if not recording
+	recordMu  sync.Mutex
+	recording []string
+
+	options Options
+}
+
+// Exec sends a lua command to the console, the command must be a single line
+func (c *Console) Exec(cmd string) error {
+	return c.exec(cmd, true)
+}
+
+// exec sends cmd to the console, cmd is appended to the recording macro if record
+func (c *Console) exec(cmd string, record bool) error {
+	if c.stdin == nil || c.stdin.Closed() {
+		return ErrClosed
+	}
+
+	cmd = strings.TrimSpace(cmd)
+	if len(cmd) == 0 {
+		return nil
+	}
+	if strings.ContainsAny(cmd, "\r\n") {
+		return errcode.New(errcode.InvalidCommand, "console command must be a single line")
+	}
+
+	c.stdin.Send([]byte(cmd + "\n"))
+
+	if record {
+		c.recordMu.Lock()
+		if c.recording != nil {
+			c.recording = append(c.recording, cmd)
+		}
+		c.recordMu.Unlock()
+	}
+	return nil
+}
+
+// ExecSnippet renders the named snippet with params and sends it to the console
+func (c *Console) ExecSnippet(name string, params Params) error {
+	cmd, err := c.options.Library.Render(name, params)
+	if err != nil {
+		return err
+	}
+	return c.Exec(cmd)
+}
+
+// Library returns the snippet library used by the console
+func (c *Console) Library() *Library {
+	return c.options.Library
+}
@@ -0,0 +1,96 @@
+package console
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsoleScript behaves like the server console, it answers queries with $RESULT and echoes other lines
+const fakeConsoleScript = `while IFS= read -r line; do
+  tag=$(printf '%s' "$line" | sed -n 's/^print("\(\[[^"]*\)" .. "\] ".*/\1/p')
+  if [ -n "$tag" ]; then
+    echo "[00:00:01]: $tag] $RESULT"
+  else
+    echo "[00:00:01]: $line"
+  fi
+done`
+
+// newFakeConsole starts the fake console process answering queries with result, returns a console with stdout attached
+func newFakeConsole(t *testing.T, result string, options ...Option) (*proc.Proc, *Console) {
+	p, err := proc.NewProc(
+		context.Background(),
+		proc.WithCommand("bash", "-c", fakeConsoleScript),
+		proc.WithEnv(map[string]string{"RESULT": result}),
+		proc.WithStdin(),
+		proc.WithStdout(),
+	)
+	require.NoError(t, err)
+
+	stdin := p.StdinPipe("console")
+	stdout := p.StdoutPipe("console")
+	require.NoError(t, p.Start())
+
+	return p, NewConsole(stdin, append([]Option{WithStdout(stdout)}, options...)...)
+}
+
+func TestConsole_Query(t *testing.T) {
+	p, console := newFakeConsole(t, "12 2")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := console.Query(ctx, "return #AllPlayers")
+	require.NoError(t, err)
+	require.Equal(t, "12 2", result)
+
+	t.Log(p.Terminate())
+
+	_, err = NewConsole(nil).Query(ctx, "return 1")
+	require.ErrorIs(t, err, ErrNoStdout)
+}
+
+func TestConsole_ExecSnippet(t *testing.T) {
+	ctx := context.Background()
+	// cat echoes the commands back, just like a console that prints what it received
+	p, err := proc.NewProc(
+		ctx,
+		proc.WithCommand("cat"),
+		proc.WithStdin(),
+		proc.WithStdout(),
+	)
+	require.NoError(t, err)
+
+	stdin := p.StdinPipe("console")
+	stdout := p.StdoutPipe("console")
+
+	var lines []string
+	done := make(chan struct{})
+	go func() {
+		for !stdout.Closed() {
+			recv, ok := stdout.Recv()
+			if ok {
+				lines = append(lines, string(recv))
+			}
+		}
+		close(done)
+	}()
+
+	require.NoError(t, p.Start())
+
+	console := NewConsole(stdin)
+	require.NoError(t, console.Exec("c_save()"))
+	require.NoError(t, console.ExecSnippet("count_prefab", Params{"prefab": "spiderden"}))
+	require.Error(t, console.Exec("print(1)\nprint(2)"))
+	require.Error(t, console.ExecSnippet("count_prefab", nil))
+
+	time.Sleep(time.Second)
+	t.Log(p.Terminate())
+	<-done
+
+	require.Equal(t, []string{`c_save()`, `c_countprefabs("spiderden")`}, lines)
+	require.ErrorIs(t, console.Exec("c_save()"), ErrClosed)
+}
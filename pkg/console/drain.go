@@ -0,0 +1,26 @@
+package console
+
+import (
+	"context"
+	"time"
+)
+
+// Drain announces message to players, waits grace for them to finish up, then saves the world,
+// it is used before restarting the shard.
+func (c *Console) Drain(ctx context.Context, message string, grace time.Duration) error {
+	if len(message) > 0 {
+		if err := c.ExecSnippet("announce", Params{"message": message}); err != nil {
+			return err
+		}
+	}
+
+	if grace > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.options.Clock.After(grace):
+		}
+	}
+
+	return c.ExecSnippet("save", nil)
+}
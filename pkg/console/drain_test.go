@@ -0,0 +1,59 @@
+package console
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+	"github.com/dstgo/dontstarve/pkg/proc/proctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsole_Drain(t *testing.T) {
+	ctx := context.Background()
+	p, err := proc.NewProc(
+		ctx,
+		proc.WithCommand("cat"),
+		proc.WithStdin(),
+		proc.WithStdout(),
+	)
+	require.NoError(t, err)
+
+	stdin := p.StdinPipe("console")
+	stdout := p.StdoutPipe("console")
+	require.NoError(t, p.Start())
+	defer func() {
+		t.Log(p.Terminate())
+	}()
+
+	clock := proctest.NewFakeClock(time.Now())
+	console := NewConsole(stdin, WithClock(clock))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- console.Drain(ctx, "restarting in 1 minute", time.Minute)
+	}()
+
+	recv, ok := stdout.Recv()
+	require.True(t, ok)
+	require.Equal(t, `c_announce("restarting in 1 minute")`, string(recv))
+
+	// the world is saved only after the grace
+	clock.BlockUntil(1)
+	select {
+	case err := <-errCh:
+		t.Fatalf("drained before the grace: %v", err)
+	default:
+	}
+	clock.Advance(time.Minute)
+	require.NoError(t, <-errCh)
+
+	recv, ok = stdout.Recv()
+	require.True(t, ok)
+	require.Equal(t, `c_save()`, string(recv))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, console.Drain(canceled, "", time.Minute), context.Canceled)
+}
@@ -0,0 +1,111 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+)
+
+// History keeps the recent records in time order, the oldest one is dropped when it is full.
+// It is kept in memory only, encode it with encoding/json to keep it across restarts.
+type History[T any] struct {
+	mu sync.RWMutex
+	// max records kept, the zero value History keeps all records
+	size    int
+	records []T
+}
+
+// Add appends record into history, the oldest one is dropped if history is full
+func (h *History[T]) Add(record T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.size > 0 && len(h.records) >= h.size {
+		h.records = slices.Delete(h.records, 0, len(h.records)-h.size+1)
+	}
+	h.records = append(h.records, record)
+}
+
+// List returns all records in time order
+func (h *History[T]) List() []T {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return slices.Clone(h.records)
+}
+
+// Latest returns the latest record
+func (h *History[T]) Latest() (T, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.records) == 0 {
+		var zero T
+		return zero, false
+	}
+	return h.records[len(h.records)-1], true
+}
+
+// MarshalJSON encodes the records in time order
+func (h *History[T]) MarshalJSON() ([]byte, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.records == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(h.records)
+}
+
+// UnmarshalJSON replaces the records with the decoded ones, the oldest ones beyond the size are dropped,
+// all of them are kept by the zero value History
+func (h *History[T]) UnmarshalJSON(data []byte) error {
+	var records []T
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.size > 0 {
+		records = records[max(len(records)-h.size, 0):]
+	}
+	h.records = records
+	return nil
+}
+
+// runPeriodic takes a record every interval on clock and adds it into history until ctx is done,
+// a record that is not taken within interval is skipped.
+func runPeriodic[T any](ctx context.Context, clock proc.Clock, name string, interval time.Duration, take func(ctx context.Context) (T, error), history *History[T]) error {
+	if interval <= 0 {
+		return fmt.Errorf("%s: invalid interval %s", name, interval)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-clock.After(interval):
+		}
+
+		takeCtx, cancel := context.WithTimeout(ctx, interval)
+		record, err := take(takeCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return nil
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		history.Add(record)
+	}
+}
@@ -0,0 +1,64 @@
+package console
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc/proctest"
+	"github.com/stretchr/testify/require"
+)
+
+// advanceRuns advances clock by interval n times, each once the periodic run is waiting on clock,
+// and returns once the run is waiting again, so the last record was added.
+func advanceRuns(clock *proctest.FakeClock, interval time.Duration, n int) {
+	for range n {
+		clock.BlockUntil(1)
+		clock.Advance(interval)
+	}
+	clock.BlockUntil(1)
+}
+
+func TestHistory_JSON(t *testing.T) {
+	history := NewWorldStatsHistory(3)
+	for day := range 4 {
+		history.Add(WorldStats{Time: time.Date(2026, 10, 12, day, 0, 0, 0, time.UTC), Day: day})
+	}
+
+	data, err := json.Marshal(history)
+	require.NoError(t, err)
+	t.Log(string(data))
+
+	// the history loaded keeps its own size
+	loaded := NewWorldStatsHistory(2)
+	require.NoError(t, json.Unmarshal(data, loaded))
+	require.Equal(t, history.List()[1:], loaded.List())
+
+	latest, ok := loaded.Latest()
+	require.True(t, ok)
+	require.Equal(t, 3, latest.Day)
+
+	data, err = json.Marshal(NewCensusHistory(1))
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(data))
+}
+
+func TestHistory_ZeroValue(t *testing.T) {
+	var history History[int]
+	for i := range 5 {
+		history.Add(i)
+	}
+	require.Equal(t, []int{0, 1, 2, 3, 4}, history.List())
+
+	data, err := json.Marshal(&history)
+	require.NoError(t, err)
+
+	// a fresh history keeps all records decoded
+	var loaded History[int]
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	require.Equal(t, history.List(), loaded.List())
+
+	var stats WorldStatsHistory
+	require.NoError(t, json.Unmarshal([]byte(`[{"day":1},{"day":2}]`), &stats))
+	require.Len(t, stats.List(), 2)
+}
@@ -0,0 +1,253 @@
+package console
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// luaTokenKind is the kind of lua token
+type luaTokenKind int
+
+const (
+	luaSpace luaTokenKind = iota
+	luaComment
+	luaString
+	luaNumber
+	luaName
+	luaSymbol
+)
+
+// luaToken is a token of lua code, the text is the source as is, so joining all tokens gives back the code
+type luaToken struct {
+	kind luaTokenKind
+	text string
+}
+
+// luaLex splits lua code into tokens, it only knows as much lua as needed to find comments, strings and literals,
+// multi-char operators are split into single chars.
+func luaLex(code string) ([]luaToken, error) {
+	var tokens []luaToken
+	for i := 0; i < len(code); {
+		c := code[i]
+		start := i
+
+		var kind luaTokenKind
+		switch {
+		case isLuaSpace(c):
+			kind = luaSpace
+			for i < len(code) && isLuaSpace(code[i]) {
+				i++
+			}
+		case strings.HasPrefix(code[i:], "--"):
+			kind = luaComment
+			i += 2
+			if level, ok := longBracket(code[i:]); ok {
+				end := strings.Index(code[i:], "]"+strings.Repeat("=", level)+"]")
+				if end < 0 {
+					return nil, fmt.Errorf("unfinished long comment at %d", start)
+				}
+				i += end + level + 2
+			} else if end := strings.IndexByte(code[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(code)
+			}
+		case c == '"' || c == '\'':
+			kind = luaString
+			i++
+			for ; i < len(code) && code[i] != c; i++ {
+				if code[i] == '\\' {
+					i++
+				} else if code[i] == '\n' {
+					break
+				}
+			}
+			if i >= len(code) || code[i] != c {
+				return nil, fmt.Errorf("unfinished string at %d", start)
+			}
+			i++
+		case c == '[':
+			level, ok := longBracket(code[i:])
+			if !ok {
+				kind = luaSymbol
+				i++
+				break
+			}
+			kind = luaString
+			end := strings.Index(code[i+level+2:], "]"+strings.Repeat("=", level)+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("unfinished long string at %d", start)
+			}
+			i += level + 2 + end + level + 2
+		case isLuaDigit(c) || (c == '.' && i+1 < len(code) && isLuaDigit(code[i+1])):
+			kind = luaNumber
+			hex := strings.HasPrefix(code[i:], "0x") || strings.HasPrefix(code[i:], "0X")
+			if hex {
+				i += 2
+			}
+			for i < len(code) {
+				d := code[i]
+				exponent := (!hex && (d == 'e' || d == 'E')) || (hex && (d == 'p' || d == 'P'))
+				if exponent && i+1 < len(code) && (code[i+1] == '+' || code[i+1] == '-') {
+					i += 2
+				} else if isLuaNameChar(d) || d == '.' {
+					i++
+				} else {
+					break
+				}
+			}
+		case isLuaNameChar(c):
+			kind = luaName
+			for i < len(code) && isLuaNameChar(code[i]) {
+				i++
+			}
+		default:
+			kind = luaSymbol
+			i++
+		}
+		tokens = append(tokens, luaToken{kind: kind, text: code[start:i]})
+	}
+	return tokens, nil
+}
+
+// longBracket returns the level of the long bracket s starts with, such as 0 for [[ and 2 for [==[
+func longBracket(s string) (int, bool) {
+	if len(s) < 2 || s[0] != '[' {
+		return 0, false
+	}
+	level := 1
+	for level < len(s) && s[level] == '=' {
+		level++
+	}
+	if level < len(s) && s[level] == '[' {
+		return level - 1, true
+	}
+	return 0, false
+}
+
+// longStringValue returns the content of a long string token, the first newline is skipped like lua does
+func longStringValue(text string) string {
+	level, _ := longBracket(text)
+	value := text[level+2 : len(text)-level-2]
+	if strings.HasPrefix(value, "\r\n") {
+		return value[2:]
+	}
+	return strings.TrimPrefix(value, "\n")
+}
+
+func isLuaSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isLuaDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLuaNameChar(c byte) bool {
+	return c == '_' || isLuaDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// joinLines joins lua code into one line, whitespaces are collapsed, comments are dropped because they would
+// comment out the rest of the joined line, and long strings spanning lines are rewritten as quoted strings.
+func joinLines(code string) (string, error) {
+	tokens, err := luaLex(code)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	separate := func() {
+		if builder.Len() > 0 && !strings.HasSuffix(builder.String(), " ") {
+			builder.WriteByte(' ')
+		}
+	}
+	for _, token := range tokens {
+		switch {
+		case token.kind == luaComment || token.kind == luaSpace:
+			separate()
+		case token.kind == luaString && token.text[0] == '[' && strings.ContainsAny(token.text, "\r\n"):
+			builder.WriteString(luaQuote(longStringValue(token.text)))
+		default:
+			builder.WriteString(token.text)
+		}
+	}
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// luaStringValue returns the value of a string token, false if it is not a string or has escapes not supported
+func luaStringValue(token luaToken) (string, bool) {
+	if token.kind != luaString {
+		return "", false
+	}
+	if token.text[0] == '[' {
+		return longStringValue(token.text), true
+	}
+
+	body := token.text[1 : len(token.text)-1]
+	var builder strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c != '\\' {
+			builder.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i >= len(body) {
+			return "", false
+		}
+		switch e := body[i]; e {
+		case 'a':
+			builder.WriteByte('\a')
+		case 'b':
+			builder.WriteByte('\b')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'n', '\n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		case 'v':
+			builder.WriteByte('\v')
+		case '\\', '"', '\'':
+			builder.WriteByte(e)
+		default:
+			if !isLuaDigit(e) {
+				return "", false
+			}
+			n := 0
+			for j := 0; j < 3 && i < len(body) && isLuaDigit(body[i]); j++ {
+				n = n*10 + int(body[i]-'0')
+				i++
+			}
+			i--
+			if n > 255 {
+				return "", false
+			}
+			builder.WriteByte(byte(n))
+		}
+	}
+	return builder.String(), true
+}
+
+// luaNumberValue parses a lua number literal, with an optional minus sign
+func luaNumberValue(text string) (float64, bool) {
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(text, "-")
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		n, err := strconv.ParseInt(text, 0, 64)
+		if err != nil {
+			return 0, false
+		}
+		value = float64(n)
+	}
+	if negative {
+		value = -value
+	}
+	return value, true
+}
@@ -0,0 +1,73 @@
+package console
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLuaLex(t *testing.T) {
+	code := `c_spawn("beefalo", 10) -- spawn
+local s = 'it\'s' .. [==[a]]b]==] --[[ block
+comment ]] x = 0x1F + 1.5e-3 + .5`
+
+	tokens, err := luaLex(code)
+	require.NoError(t, err)
+
+	var joined strings.Builder
+	var literals []string
+	for _, token := range tokens {
+		joined.WriteString(token.text)
+		if token.kind == luaString || token.kind == luaNumber || token.kind == luaComment {
+			literals = append(literals, token.text)
+		}
+	}
+	require.Equal(t, code, joined.String())
+	require.Equal(t, []string{
+		`"beefalo"`, `10`, `-- spawn`,
+		`'it\'s'`, `[==[a]]b]==]`, "--[[ block\ncomment ]]",
+		`0x1F`, `1.5e-3`, `.5`,
+	}, literals)
+
+	for _, bad := range []string{`print("a)`, `print([[a)`, `--[[ a`, "print('a\nb')"} {
+		_, err := luaLex(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestJoinLines(t *testing.T) {
+	samples := []struct {
+		code   string
+		expect string
+	}{
+		{"c_save()", "c_save()"},
+		{"local n = 1 -- count\nprint(n)", "local n = 1 print(n)"},
+		{"local a = 1 --[[ a\nblock ]] print(a)\n--[==[ another ]==]\nprint(2)", "local a = 1 print(a) print(2)"},
+		{`print("-- not a comment") -- comment`, `print("-- not a comment")`},
+		{"print([[line1\nline2]])", `print("line1\nline2")`},
+		{"print([[\nfirst newline skipped]])", `print("first newline skipped")`},
+		{"  if x then\n\n    y()\n  end  ", "if x then y() end"},
+	}
+
+	for _, sample := range samples {
+		joined, err := joinLines(sample.code)
+		require.NoError(t, err)
+		require.Equal(t, sample.expect, joined)
+	}
+
+	_, err := joinLines(`print("a`)
+	require.Error(t, err)
+}
+
+func TestLuaValue(t *testing.T) {
+	for _, v := range []any{math.NaN(), math.Inf(1), math.Inf(-1), float32(math.Inf(1))} {
+		_, err := luaValue(v)
+		require.Error(t, err)
+	}
+
+	value, err := luaValue(1.5)
+	require.NoError(t, err)
+	require.Equal(t, "1.5", value)
+}
@@ -0,0 +1,217 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+)
+
+// Macro is a named sequence of console commands recorded from the console, which can be replayed later.
+// Commands are in text/template syntax like snippets, params are rendered as lua literals.
+type Macro struct {
+	Name string `json:"name"`
+	// names of required params
+	Params   []string `json:"params"`
+	Commands []string `json:"commands"`
+}
+
+// NewMacro makes a macro from recorded commands, each lua literal token equals to the value of a param is replaced
+// with the param, for example c_announce("hello") with {"message": "hello"} becomes c_announce({{.message}}).
+// Only whole tokens are replaced, so with {"n": 1} the 10 in c_spawn("beefalo", 10) is left alone.
+// If values of params equal, the first param in name order wins.
+func NewMacro(name string, commands []string, params Params) (Macro, error) {
+	if len(name) == 0 {
+		return Macro{}, errors.New("macro name is empty")
+	}
+
+	macro := Macro{Name: name}
+	for param, v := range params {
+		if _, err := luaValue(v); err != nil {
+			return Macro{}, errcode.Errorf(errcode.InvalidParams, "macro %s: param %s: %w", name, param, err)
+		}
+		macro.Params = append(macro.Params, param)
+	}
+	slices.Sort(macro.Params)
+
+	for i, cmd := range commands {
+		tokens, err := luaLex(cmd)
+		if err != nil {
+			return Macro{}, errcode.Errorf(errcode.InvalidCommand, "macro %s: command %d: %w", name, i, err)
+		}
+
+		// commands are literal lua, the text between params is escaped as a whole, because delimiters may
+		// span tokens, such as nested tables {{1}}
+		var builder, literal strings.Builder
+		writeParam := func(param string) {
+			builder.WriteString(escapeDelims(literal.String(), true))
+			literal.Reset()
+			builder.WriteString("{{." + param + "}}")
+		}
+		for j := 0; j < len(tokens); j++ {
+			token := tokens[j]
+			// a negative number is a unary minus followed by a number
+			if token.text == "-" && j+1 < len(tokens) && tokens[j+1].kind == luaNumber && isUnary(tokens[:j]) {
+				if param, ok := matchParam(macro.Params, params, luaToken{kind: luaNumber, text: "-" + tokens[j+1].text}); ok {
+					writeParam(param)
+					j++
+					continue
+				}
+			}
+			if param, ok := matchParam(macro.Params, params, token); ok {
+				writeParam(param)
+				continue
+			}
+			literal.WriteString(token.text)
+		}
+		builder.WriteString(escapeDelims(literal.String(), false))
+		macro.Commands = append(macro.Commands, builder.String())
+	}
+	return macro, nil
+}
+
+// escapeDelims escapes each { of text which would start a template action, that is followed by another {,
+// or ends text before a param action if beforeAction
+func escapeDelims(text string, beforeAction bool) string {
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '{' && (i+1 < len(text) && text[i+1] == '{' || i+1 == len(text) && beforeAction) {
+			builder.WriteString(`{{"{"}}`)
+			continue
+		}
+		builder.WriteByte(text[i])
+	}
+	return builder.String()
+}
+
+// matchParam returns the first of names whose value in params equals to the literal token
+func matchParam(names []string, params Params, token luaToken) (string, bool) {
+	for _, name := range names {
+		if luaLiteralEqual(token, params[name]) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// luaLiteralEqual returns whether token is a literal of v
+func luaLiteralEqual(token luaToken, v any) bool {
+	switch val := v.(type) {
+	case string:
+		value, ok := luaStringValue(token)
+		return ok && value == val
+	case bool:
+		return token.kind == luaName && token.text == strconv.FormatBool(val)
+	default:
+		if token.kind != luaNumber {
+			return false
+		}
+		literal, err := luaValue(v)
+		if err != nil {
+			return false
+		}
+		a, ok := luaNumberValue(token.text)
+		b, ok2 := luaNumberValue(literal)
+		return ok && ok2 && a == b
+	}
+}
+
+// isUnary returns whether a minus after tokens is unary, which is not after an operand
+func isUnary(tokens []luaToken) bool {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		token := tokens[i]
+		switch token.kind {
+		case luaSpace, luaComment:
+			continue
+		case luaNumber, luaString:
+			return false
+		case luaName:
+			// keywords are not operands
+			return slices.Contains([]string{"and", "or", "not", "return", "in", "do", "then", "else", "until"}, token.text)
+		default:
+			return token.text != ")" && token.text != "]" && token.text != "}"
+		}
+	}
+	return true
+}
+
+// Render renders the commands of macro with params
+func (m Macro) Render(params Params) ([]string, error) {
+	for _, param := range m.Params {
+		if _, ok := params[param]; !ok {
+			return nil, errcode.Errorf(errcode.InvalidParams, "macro %s: missing param %s", m.Name, param)
+		}
+	}
+
+	values := make(map[string]string, len(params))
+	for k, v := range params {
+		value, err := luaValue(v)
+		if err != nil {
+			return nil, errcode.Errorf(errcode.InvalidParams, "macro %s: param %s: %w", m.Name, k, err)
+		}
+		values[k] = value
+	}
+
+	commands := make([]string, 0, len(m.Commands))
+	for i, cmd := range m.Commands {
+		tmpl, err := template.New(m.Name).Option("missingkey=error").Parse(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("macro %s: command %d: %w", m.Name, i, err)
+		}
+
+		var builder strings.Builder
+		if err := tmpl.Execute(&builder, values); err != nil {
+			return nil, fmt.Errorf("macro %s: command %d: %w", m.Name, i, err)
+		}
+		joined, err := joinLines(builder.String())
+		if err != nil {
+			return nil, fmt.Errorf("macro %s: command %d: %w", m.Name, i, err)
+		}
+		commands = append(commands, joined)
+	}
+	return commands, nil
+}
+
+// StartRecording starts recording commands sent by Exec and ExecSnippet, queries are not recorded
+func (c *Console) StartRecording() error {
+	c.recordMu.Lock()
+	defer c.recordMu.Unlock()
+
+	if c.recording != nil {
+		return errors.New("console is already recording")
+	}
+	c.recording = []string{}
+	return nil
+}
+
+// StopRecording stops recording and returns the recorded commands as a macro, see NewMacro for params
+func (c *Console) StopRecording(name string, params Params) (Macro, error) {
+	c.recordMu.Lock()
+	commands := c.recording
+	c.recording = nil
+	c.recordMu.Unlock()
+
+	if commands == nil {
+		return Macro{}, errors.New("console is not recording")
+	}
+	return NewMacro(name, commands, params)
+}
+
+// Replay renders macro with params and sends the commands to the console in order,
+// nothing is sent if rendering fails.
+func (c *Console) Replay(macro Macro, params Params) error {
+	commands, err := macro.Render(params)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range commands {
+		if err := c.Exec(cmd); err != nil {
+			return fmt.Errorf("macro %s: %w", macro.Name, err)
+		}
+	}
+	return nil
+}
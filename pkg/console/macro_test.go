@@ -0,0 +1,142 @@
+package console
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+	"github.com/dstgo/dontstarve/pkg/proc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMacro(t *testing.T) {
+	macro, err := NewMacro("fixup", []string{
+		`c_announce("rolled back, sorry Wilson")`,
+		`c_removeall("spiderden")`,
+		`print("{{not a template}}")`,
+	}, Params{"who": "Wilson", "message": "rolled back, sorry Wilson", "prefab": "spiderden"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"message", "prefab", "who"}, macro.Params)
+	t.Log(macro.Commands)
+
+	commands, err := macro.Render(Params{"who": "Wes", "message": "back in 5", "prefab": "houndmound"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`c_announce("back in 5")`,
+		`c_removeall("houndmound")`,
+		`print("{{not a template}}")`,
+	}, commands)
+
+	_, err = macro.Render(Params{"message": "hi"})
+	require.Equal(t, errcode.InvalidParams, errcode.Of(err))
+
+	_, err = NewMacro("", nil, nil)
+	require.Error(t, err)
+}
+
+func TestNewMacro_WholeTokens(t *testing.T) {
+	macro, err := NewMacro("spawn", []string{
+		`c_spawn("beefalo", 10)`,
+		`c_spawn('beefalo', 1)`,
+		`c_give("beefalo_hat", 1.0)`,
+		`c_sethealth(-1) c_setsanity(x-1) c_godmode(true)`,
+	}, Params{"n": 1, "prefab": "beefalo", "enabled": true, "neg": -1})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`c_spawn({{.prefab}}, 10)`,
+		`c_spawn({{.prefab}}, {{.n}})`,
+		`c_give("beefalo_hat", {{.n}})`,
+		`c_sethealth({{.neg}}) c_setsanity(x-{{.n}}) c_godmode({{.enabled}})`,
+	}, macro.Commands)
+
+	commands, err := macro.Render(Params{"n": 5, "prefab": "koalefant_summer", "enabled": false, "neg": -2})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`c_spawn("koalefant_summer", 10)`,
+		`c_spawn("koalefant_summer", 5)`,
+		`c_give("beefalo_hat", 5)`,
+		`c_sethealth(-2) c_setsanity(x-5) c_godmode(false)`,
+	}, commands)
+
+	_, err = NewMacro("broken", []string{`print("a)`}, nil)
+	require.Equal(t, errcode.InvalidCommand, errcode.Of(err))
+}
+
+func TestNewMacro_NestedTables(t *testing.T) {
+	recorded := []string{
+		`local t = {{1}} print(#t)`,
+		`local t = {{1, 2}, {x=1}}`,
+		`local t = {{x=1}}`,
+		`local t = {{{n=3}}}`,
+		`c_spawn("beefalo", {{"beefalo"}})`,
+		`print("{{not a template}}")`,
+	}
+	macro, err := NewMacro("tables", recorded, Params{"n": 3, "prefab": "beefalo"})
+	require.NoError(t, err)
+	t.Log(macro.Commands)
+
+	commands, err := macro.Render(Params{"n": 3, "prefab": "beefalo"})
+	require.NoError(t, err)
+	require.Equal(t, recorded, commands)
+
+	commands, err = macro.Render(Params{"n": 5, "prefab": "koalefant_summer"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`local t = {{1}} print(#t)`,
+		`local t = {{1, 2}, {x=1}}`,
+		`local t = {{x=1}}`,
+		`local t = {{{n=5}}}`,
+		`c_spawn("koalefant_summer", {{"koalefant_summer"}})`,
+		`print("{{not a template}}")`,
+	}, commands)
+}
+
+func TestConsole_Replay(t *testing.T) {
+	ctx := context.Background()
+	// the commands received are written to the file, so they are checked after the process exited
+	out := filepath.Join(t.TempDir(), "stdin.txt")
+	p, err := proc.NewProc(
+		ctx,
+		proc.WithCommand("bash", "-c", `cat > "$OUT"`),
+		proc.WithEnv(map[string]string{"OUT": out}),
+		proc.WithStdin(),
+	)
+	require.NoError(t, err)
+
+	stdin := p.StdinPipe("console")
+	require.NoError(t, p.Start())
+
+	console := NewConsole(stdin)
+	_, err = console.StopRecording("fixup", nil)
+	require.Error(t, err)
+
+	require.NoError(t, console.StartRecording())
+	require.Error(t, console.StartRecording())
+	require.NoError(t, console.ExecSnippet("remove_prefab", Params{"prefab": "spiderden"}))
+	require.NoError(t, console.Exec("c_save()"))
+	macro, err := console.StopRecording("fixup", Params{"prefab": "spiderden"})
+	require.NoError(t, err)
+	require.Equal(t, []string{`c_removeall({{.prefab}})`, `c_save()`}, macro.Commands)
+
+	// not recorded any more
+	require.NoError(t, console.Exec("c_listallplayers()"))
+	require.NoError(t, console.Replay(macro, Params{"prefab": "houndmound"}))
+	require.Error(t, console.Replay(macro, nil))
+
+	time.Sleep(time.Second)
+	t.Log(p.Terminate())
+
+	content, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`c_removeall("spiderden")`,
+		`c_save()`,
+		`c_listallplayers()`,
+		`c_removeall("houndmound")`,
+		`c_save()`,
+	}, strings.Split(strings.TrimSpace(string(content)), "\n"))
+}
@@ -0,0 +1,159 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TileSize is the size of a map tile in world units
+const TileSize = 4
+
+const (
+	PointPlayer = "player"
+	PointPOI    = "poi"
+)
+
+// DefaultPointsOfInterest are the prefabs of landmarks in forest and caves
+var DefaultPointsOfInterest = []string{
+	"multiplayer_portal", "pigking", "moonbase", "critterlab", "oasislake",
+	"dragonfly_spawner", "beequeenhive", "cave_entrance", "cave_exit", "ancient_altar", "atrium_gate",
+}
+
+// MapPoint is a player or point of interest on the map
+type MapPoint struct {
+	Kind   string `json:"kind"`
+	Prefab string `json:"prefab"`
+	// name of player, empty for points of interest
+	Name string  `json:"name,omitempty"`
+	X    float64 `json:"x"`
+	Z    float64 `json:"z"`
+}
+
+// MapSnapshot is the positions on the map at a moment
+type MapSnapshot struct {
+	Time time.Time `json:"time"`
+	// size of the map in tiles, world origin is at the map center
+	Width  int        `json:"width"`
+	Height int        `json:"height"`
+	Points []MapPoint `json:"points"`
+}
+
+// MapSnapshot collects positions of players and entities of prefabs, DefaultPointsOfInterest if prefabs is empty
+func (c *Console) MapSnapshot(ctx context.Context, prefabs []string) (MapSnapshot, error) {
+	if len(prefabs) == 0 {
+		prefabs = DefaultPointsOfInterest
+	}
+
+	result, err := c.QuerySnippet(ctx, "map_points", Params{"prefabs": strings.Join(prefabs, ",")})
+	if err != nil {
+		return MapSnapshot{}, err
+	}
+	snapshot, err := parseMapSnapshot(result)
+	if err != nil {
+		return MapSnapshot{}, err
+	}
+	snapshot.Time = c.options.Clock.Now()
+	return snapshot, nil
+}
+
+func parseMapSnapshot(result string) (MapSnapshot, error) {
+	var snapshot MapSnapshot
+	for _, point := range strings.Split(result, ";") {
+		fields := strings.Split(point, "|")
+		if len(fields) != 5 {
+			return MapSnapshot{}, fmt.Errorf("map snapshot: unexpected result %q", point)
+		}
+
+		if fields[0] == "size" {
+			width, err1 := strconv.Atoi(fields[2])
+			height, err2 := strconv.Atoi(fields[3])
+			if err1 != nil || err2 != nil {
+				return MapSnapshot{}, fmt.Errorf("map snapshot: unexpected result %q", point)
+			}
+			snapshot.Width, snapshot.Height = width, height
+			continue
+		}
+
+		x, err1 := strconv.ParseFloat(fields[3], 64)
+		z, err2 := strconv.ParseFloat(fields[4], 64)
+		if err1 != nil || err2 != nil {
+			return MapSnapshot{}, fmt.Errorf("map snapshot: unexpected result %q", point)
+		}
+		snapshot.Points = append(snapshot.Points, MapPoint{Kind: fields[0], Prefab: fields[1], Name: fields[2], X: x, Z: z})
+	}
+	return snapshot, nil
+}
+
+// MapRenderer renders snapshot into writer, such as an overlay for status page
+type MapRenderer interface {
+	Render(writer io.Writer, snapshot MapSnapshot) error
+}
+
+// JSONRenderer renders snapshot as json
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(writer io.Writer, snapshot MapSnapshot) error {
+	return json.NewEncoder(writer).Encode(snapshot)
+}
+
+// PNGRenderer renders points as dots on a transparent square png, x goes right and z goes down
+type PNGRenderer struct {
+	// width and height of the image in pixels, default 512
+	Size   int
+	Player color.Color
+	POI    color.Color
+}
+
+func (r PNGRenderer) Render(writer io.Writer, snapshot MapSnapshot) error {
+	if snapshot.Width <= 0 || snapshot.Height <= 0 {
+		return fmt.Errorf("map snapshot: invalid map size %dx%d", snapshot.Width, snapshot.Height)
+	}
+
+	size := r.Size
+	if size <= 0 {
+		size = 512
+	}
+	playerColor, poiColor := r.Player, r.POI
+	if playerColor == nil {
+		playerColor = color.RGBA{R: 0xe0, G: 0x30, B: 0x30, A: 0xff}
+	}
+	if poiColor == nil {
+		poiColor = color.RGBA{R: 0xf0, G: 0xc0, B: 0x20, A: 0xff}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	worldWidth := float64(snapshot.Width * TileSize)
+	worldHeight := float64(snapshot.Height * TileSize)
+
+	// players are drawn last to stay on top
+	for _, kind := range []string{PointPOI, PointPlayer} {
+		c, radius := poiColor, max(size/128, 1)
+		if kind == PointPlayer {
+			c, radius = playerColor, max(size/96, 2)
+		}
+
+		for _, point := range snapshot.Points {
+			if point.Kind != kind {
+				continue
+			}
+			px := int((point.X/worldWidth + 0.5) * float64(size))
+			py := int((point.Z/worldHeight + 0.5) * float64(size))
+			for x := px - radius; x <= px+radius; x++ {
+				for y := py - radius; y <= py+radius; y++ {
+					// out of bounds is ignored by Set
+					img.Set(x, y, c)
+				}
+			}
+		}
+	}
+
+	return png.Encode(writer, img)
+}
@@ -0,0 +1,59 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc/proctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsole_MapSnapshot(t *testing.T) {
+	clock := proctest.NewFakeClock(time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC))
+	p, console := newFakeConsole(t, "size||100|80|;player|wilson|Alice|40.00|-20.50;poi|pigking||0.00|0.00", WithClock(clock))
+	defer func() {
+		t.Log(p.Terminate())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	snapshot, err := console.MapSnapshot(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, clock.Now(), snapshot.Time)
+	require.Equal(t, 100, snapshot.Width)
+	require.Equal(t, 80, snapshot.Height)
+	require.Equal(t, []MapPoint{
+		{Kind: PointPlayer, Prefab: "wilson", Name: "Alice", X: 40, Z: -20.5},
+		{Kind: PointPOI, Prefab: "pigking"},
+	}, snapshot.Points)
+
+	var buf bytes.Buffer
+	require.NoError(t, JSONRenderer{}.Render(&buf, snapshot))
+	t.Log(buf.String())
+
+	var decoded MapSnapshot
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, snapshot.Points, decoded.Points)
+
+	buf.Reset()
+	require.NoError(t, PNGRenderer{Size: 200}.Render(&buf, snapshot))
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	require.Equal(t, 200, img.Bounds().Dx())
+
+	// pigking at the center, player at x=40/400, z=-20.5/320 from the center
+	_, _, _, alpha := img.At(100, 100).RGBA()
+	require.NotZero(t, alpha)
+	red, _, _, _ := img.At(120, 87).RGBA()
+	require.Equal(t, uint32(0xe0e0), red)
+	_, _, _, alpha = img.At(10, 10).RGBA()
+	require.Zero(t, alpha)
+
+	_, err = parseMapSnapshot("player|wilson|Alice|x|1")
+	require.Error(t, err)
+}
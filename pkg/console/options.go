@@ -0,0 +1,33 @@
+package console
+
+import "github.com/dstgo/dontstarve/pkg/proc"
+
+type Options struct {
+	// snippet library, use builtin snippets if nil
+	Library *Library
+	// stdout stream of the server process, required by queries
+	Stdout *proc.Stream
+	// source of time of periodic jobs, snapshots and the grace of Drain, use proc.RealClock if nil
+	Clock proc.Clock
+}
+
+// Option apply option into *Options
+type Option func(*Options)
+
+func WithLibrary(library *Library) Option {
+	return func(opt *Options) {
+		opt.Library = library
+	}
+}
+
+func WithStdout(stdout *proc.Stream) Option {
+	return func(opt *Options) {
+		opt.Stdout = stdout
+	}
+}
+
+func WithClock(clock proc.Clock) Option {
+	return func(opt *Options) {
+		opt.Clock = clock
+	}
+}
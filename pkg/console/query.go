@@ -0,0 +1,116 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+)
+
+// ErrNoStdout returned when querying a console created without stdout stream
+var ErrNoStdout = errcode.New(errcode.ConsoleNoStdout, "console has no stdout stream")
+
+// Query runs a lua chunk in the console and returns the value it returns as string,
+// for example "return #AllPlayers". It requires the stdout stream of the server.
+func (c *Console) Query(ctx context.Context, lua string) (string, error) {
+	if c.options.Stdout == nil {
+		return "", ErrNoStdout
+	}
+
+	chunk, err := joinLines(lua)
+	if err != nil {
+		return "", err
+	}
+
+	id := strconv.FormatUint(c.queryID.Add(1), 10)
+	resultCh := make(chan string, 1)
+
+	c.queryMu.Lock()
+	if c.queryClosed {
+		c.queryMu.Unlock()
+		return "", ErrClosed
+	}
+	c.queries[id] = resultCh
+	c.queryMu.Unlock()
+
+	defer func() {
+		c.queryMu.Lock()
+		delete(c.queries, id)
+		c.queryMu.Unlock()
+	}()
+
+	// the tag is concatenated in lua, so the echo of command itself never matches the result
+	cmd := fmt.Sprintf(`print(%s .. %s .. tostring((function() %s end)()))`,
+		luaQuote("["+c.queryTag+":"+id), luaQuote("] "), chunk)
+	// queries are not recorded, the tag only makes sense to this console
+	if err := c.exec(cmd, false); err != nil {
+		return "", err
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case result, ok := <-resultCh:
+		if !ok {
+			return "", ErrClosed
+		}
+		return result, nil
+	}
+}
+
+// QuerySnippet renders the named snippet with params and queries it
+func (c *Console) QuerySnippet(ctx context.Context, name string, params Params) (string, error) {
+	lua, err := c.options.Library.Render(name, params)
+	if err != nil {
+		return "", err
+	}
+	return c.Query(ctx, lua)
+}
+
+// readStdout dispatches query results from stdout until the stream closed
+func (c *Console) readStdout() {
+	for {
+		line, ok := c.options.Stdout.Recv()
+		if !ok {
+			break
+		}
+
+		id, result, ok := c.parseQueryResult(string(line))
+		if !ok {
+			continue
+		}
+
+		c.queryMu.Lock()
+		if resultCh, ok := c.queries[id]; ok {
+			select {
+			case resultCh <- result:
+			default:
+			}
+		}
+		c.queryMu.Unlock()
+	}
+
+	c.queryMu.Lock()
+	c.queryClosed = true
+	for id, resultCh := range c.queries {
+		close(resultCh)
+		delete(c.queries, id)
+	}
+	c.queryMu.Unlock()
+}
+
+// parseQueryResult parses line like "[00:01:02]: [tag:id] result"
+func (c *Console) parseQueryResult(line string) (string, string, bool) {
+	prefix := "[" + c.queryTag + ":"
+	i := strings.Index(line, prefix)
+	if i < 0 {
+		return "", "", false
+	}
+	id, result, ok := strings.Cut(line[i+len(prefix):], "] ")
+	if !ok {
+		return "", "", false
+	}
+	return id, strings.TrimRight(result, "\r"), true
+}
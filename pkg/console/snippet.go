@@ -0,0 +1,416 @@
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+)
+
+// BuiltinVersion is the version of the builtin snippets, bump it when any of them changes
+const BuiltinVersion = "2"
+
+// Params is the parameters used to render a snippet, values must be string, bool or number,
+// strings are always rendered as quoted lua string literal.
+type Params map[string]any
+
+// Snippet is a named lua template which can be sent to the console
+type Snippet struct {
+	Name        string
+	Description string
+	Version     string
+	// names of required params
+	Params []string
+	// lua code in text/template syntax, rendered into a single line
+	Lua string
+
+	tmpl *template.Template
+}
+
+// builtin snippets, they should only call functions that not change the world unless the name says so
+var builtinSnippets = []Snippet{
+	{
+		Name:        "save",
+		Description: "save the world",
+		Lua:         `c_save()`,
+	},
+	{
+		Name:        "announce",
+		Description: "announce a message to all players",
+		Params:      []string{"message"},
+		Lua:         `c_announce({{.message}})`,
+	},
+	{
+		Name:        "list_players",
+		Description: "print all players in the shard",
+		Lua:         `c_listallplayers()`,
+	},
+	{
+		Name:        "kick",
+		Description: "kick the player by user id, like KU_xxxxxxxx",
+		Params:      []string{"userid"},
+		Lua:         `TheNet:Kick({{.userid}})`,
+	},
+	{
+		Name:        "count_prefab",
+		Description: "print the number of the prefab in the world",
+		Params:      []string{"prefab"},
+		Lua:         `c_countprefabs({{.prefab}})`,
+	},
+	{
+		Name:        "remove_prefab",
+		Description: "remove all entities of the prefab from the world",
+		Params:      []string{"prefab"},
+		Lua:         `c_removeall({{.prefab}})`,
+	},
+	{
+		Name:        "player_position",
+		Description: "print the world position of the named player",
+		Params:      []string{"name"},
+		Lua: `for _, player in ipairs(AllPlayers) do
+  if player.name == {{.name}} then
+    local x, y, z = player.Transform:GetWorldPosition()
+    print(string.format("%s %.2f %.2f %.2f", player.name, x, y, z))
+  end
+end`,
+	},
+	{
+		Name:        "cleanup_entities",
+		Description: "count entities of the prefab, remove the ones beyond keep if remove is true, returns count and removed. Entities on the ground are tracked since first seen, to match the ones lying there for at least min_days",
+		Params:      []string{"prefab", "on_ground", "min_days", "keep", "remove"},
+		Lua: `local seen = rawget(_G, "dst_cleanup_seen")
+if seen == nil then
+  seen = setmetatable({}, {__mode = "k"})
+  rawset(_G, "dst_cleanup_seen", seen)
+end
+local now = TheWorld.state.cycles + TheWorld.state.time
+local count, removed = 0, 0
+for _, ent in pairs(Ents) do
+  if ent.prefab == {{.prefab}} and ent:IsValid() then
+    local on_ground = ent.components.inventoryitem ~= nil and ent.components.inventoryitem.owner == nil
+    if on_ground then
+      seen[ent] = seen[ent] or now
+    else
+      seen[ent] = nil
+    end
+    local old = {{.min_days}} <= 0 or (on_ground and now - seen[ent] >= {{.min_days}})
+    if (not {{.on_ground}} or on_ground) and old then
+      count = count + 1
+      if count > {{.keep}} then
+        if {{.remove}} then ent:Remove() end
+        removed = removed + 1
+      end
+    end
+  end
+end
+return count .. " " .. removed`,
+	},
+	{
+		Name:        "entity_census",
+		Description: "returns entity counts by prefab, formatted as prefab=count separated by comma",
+		Lua: `local counts = {}
+for _, ent in pairs(Ents) do
+  if ent.prefab ~= nil then
+    counts[ent.prefab] = (counts[ent.prefab] or 0) + 1
+  end
+end
+local result = {}
+for prefab, count in pairs(counts) do
+  table.insert(result, prefab .. "=" .. count)
+end
+return table.concat(result, ",")`,
+	},
+	{
+		Name:        "world_stats",
+		Description: "returns the day, season, entity count, ghost count, deaths counted since the first call and days survived of each player, formatted as day|season|entities|ghosts|deaths|days separated by comma",
+		Lua: `if rawget(_G, "dst_world_deaths") == nil then
+  rawset(_G, "dst_world_deaths", 0)
+  local function count_deaths(player)
+    player:ListenForEvent("death", function() rawset(_G, "dst_world_deaths", rawget(_G, "dst_world_deaths") + 1) end)
+  end
+  for _, player in ipairs(AllPlayers) do
+    count_deaths(player)
+  end
+  TheWorld:ListenForEvent("ms_playerspawn", function(_, player) count_deaths(player) end)
+end
+local entities = 0
+for _ in pairs(Ents) do
+  entities = entities + 1
+end
+local ghosts, ages = 0, {}
+for _, player in ipairs(AllPlayers) do
+  if player:HasTag("playerghost") then
+    ghosts = ghosts + 1
+  end
+  local age = player.components.age ~= nil and player.components.age:GetAgeInDays() or 0
+  table.insert(ages, string.format("%.1f", age))
+end
+return (TheWorld.state.cycles + 1) .. "|" .. TheWorld.state.season .. "|" .. entities .. "|" .. ghosts .. "|" .. rawget(_G, "dst_world_deaths") .. "|" .. table.concat(ages, ",")`,
+	},
+	{
+		Name:        "map_points",
+		Description: "returns the map size, players and entities of the prefabs separated by comma, formatted as kind|prefab|name|x|z separated by semicolon",
+		Params:      []string{"prefabs"},
+		Lua: `local width, height = TheWorld.Map:GetSize()
+local points = {"size||" .. width .. "|" .. height .. "|"}
+for _, player in ipairs(AllPlayers) do
+  local x, y, z = player.Transform:GetWorldPosition()
+  table.insert(points, string.format("player|%s|%s|%.2f|%.2f", player.prefab, (player.name:gsub("[|;]", " ")), x, z))
+end
+local wanted = {}
+for prefab in string.gmatch({{.prefabs}}, "[^,]+") do
+  wanted[prefab] = true
+end
+for _, ent in pairs(Ents) do
+  if ent.prefab ~= nil and wanted[ent.prefab] and ent.Transform ~= nil then
+    local x, y, z = ent.Transform:GetWorldPosition()
+    table.insert(points, string.format("poi|%s||%.2f|%.2f", ent.prefab, x, z))
+  end
+end
+return table.concat(points, ";")`,
+	},
+}
+
+// NewLibrary return a library with builtin snippets
+func NewLibrary() *Library {
+	library := &Library{snippets: make(map[string]*Snippet)}
+	for _, snippet := range builtinSnippets {
+		snippet.Version = BuiltinVersion
+		if err := library.Add(snippet); err != nil {
+			panic(err)
+		}
+	}
+	return library
+}
+
+// Library is a collection of snippets which can be invoked by name
+type Library struct {
+	mu       sync.RWMutex
+	snippets map[string]*Snippet
+}
+
+// Add adds the snippet into library, it replaces the existing snippet with same name
+func (l *Library) Add(snippet Snippet) error {
+	if len(snippet.Name) == 0 {
+		return fmt.Errorf("snippet name is empty")
+	}
+
+	tmpl, err := template.New(snippet.Name).Option("missingkey=error").Parse(snippet.Lua)
+	if err != nil {
+		return fmt.Errorf("snippet %s: %w", snippet.Name, err)
+	}
+	snippet.tmpl = tmpl
+
+	l.mu.Lock()
+	l.snippets[snippet.Name] = &snippet
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Get returns the named snippet
+func (l *Library) Get(name string) (Snippet, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	snippet, ok := l.snippets[name]
+	if !ok {
+		return Snippet{}, false
+	}
+	return *snippet, true
+}
+
+// List returns all snippets sorted by name
+func (l *Library) List() []Snippet {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var snippets []Snippet
+	for _, snippet := range l.snippets {
+		snippets = append(snippets, *snippet)
+	}
+	slices.SortFunc(snippets, func(a, b Snippet) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return snippets
+}
+
+// Render renders the named snippet with params into a single line lua command
+func (l *Library) Render(name string, params Params) (string, error) {
+	snippet, ok := l.Get(name)
+	if !ok {
+		return "", errcode.Errorf(errcode.SnippetNotFound, "snippet not found: %s", name)
+	}
+
+	values := make(map[string]string, len(params))
+	for _, param := range snippet.Params {
+		if _, ok := params[param]; !ok {
+			return "", errcode.Errorf(errcode.InvalidParams, "snippet %s: missing param %s", name, param)
+		}
+	}
+	for k, v := range params {
+		value, err := luaValue(v)
+		if err != nil {
+			return "", errcode.Errorf(errcode.InvalidParams, "snippet %s: param %s: %w", name, k, err)
+		}
+		values[k] = value
+	}
+
+	var builder strings.Builder
+	if err := snippet.tmpl.Execute(&builder, values); err != nil {
+		return "", fmt.Errorf("snippet %s: %w", name, err)
+	}
+
+	cmd, err := joinLines(builder.String())
+	if err != nil {
+		return "", fmt.Errorf("snippet %s: %w", name, err)
+	}
+	return cmd, nil
+}
+
+// LoadDir loads all *.lua files in dir as snippets, metadata is read from the leading comments, for example
+//
+//	-- name: count_spiders
+//	-- description: count spiders in the world
+//	-- version: 1
+//	-- params: prefab, limit
+//	c_countprefabs({{.prefab}})
+//
+// the name defaults to the file name without extension.
+func (l *Library) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.lua"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		snippet, err := readSnippet(file)
+		if err != nil {
+			return err
+		}
+		if err := l.Add(snippet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readSnippet(file string) (Snippet, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return Snippet{}, err
+	}
+	defer fd.Close()
+
+	snippet := Snippet{Name: strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))}
+
+	var code []string
+	header := true
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if header {
+			if key, value, ok := parseMeta(line); ok {
+				switch key {
+				case "name":
+					snippet.Name = value
+				case "description":
+					snippet.Description = value
+				case "version":
+					snippet.Version = value
+				case "params":
+					for _, param := range strings.Split(value, ",") {
+						if param = strings.TrimSpace(param); len(param) > 0 {
+							snippet.Params = append(snippet.Params, param)
+						}
+					}
+				}
+				continue
+			}
+			header = false
+		}
+
+		code = append(code, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Snippet{}, fmt.Errorf("%s: %w", file, err)
+	}
+
+	snippet.Lua = strings.Join(code, "\n")
+	return snippet, nil
+}
+
+// parseMeta parse metadata comment like "-- key: value"
+func parseMeta(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "--") {
+		return "", "", false
+	}
+	key, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "--")), ":")
+	if !ok {
+		return "", "", false
+	}
+	return strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value), true
+}
+
+// luaValue formats v as lua literal
+func luaValue(v any) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return luaQuote(val), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(val), nil
+	case float32:
+		if math.IsNaN(float64(val)) || math.IsInf(float64(val), 0) {
+			return "", fmt.Errorf("unsupported number %v", val)
+		}
+		return strconv.FormatFloat(float64(val), 'g', -1, 32), nil
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return "", fmt.Errorf("unsupported number %v", val)
+		}
+		return strconv.FormatFloat(val, 'g', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// luaQuote quotes s as a single line lua string literal
+func luaQuote(s string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			builder.WriteString(`\"`)
+		case '\\':
+			builder.WriteString(`\\`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				builder.WriteString(fmt.Sprintf("\\%03d", c))
+			} else {
+				builder.WriteByte(c)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
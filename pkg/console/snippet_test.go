@@ -0,0 +1,80 @@
+package console
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibrary_Render(t *testing.T) {
+	library := NewLibrary()
+
+	samples := []struct {
+		name   string
+		params Params
+		expect string
+		err    bool
+	}{
+		{name: "save", expect: `c_save()`},
+		{name: "count_prefab", params: Params{"prefab": "spiderden"}, expect: `c_countprefabs("spiderden")`},
+		{name: "announce", params: Params{"message": "say \"hi\"\nend) c_reset("}, expect: `c_announce("say \"hi\"\nend) c_reset(")`},
+		{name: "announce", err: true},
+		{name: "announce", params: Params{"message": []string{"a"}}, err: true},
+		{name: "not_exist", err: true},
+	}
+
+	for _, s := range samples {
+		cmd, err := library.Render(s.name, s.params)
+		if s.err {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, s.expect, cmd)
+	}
+
+	cmd, err := library.Render("player_position", Params{"name": "wilson"})
+	require.NoError(t, err)
+	require.NotContains(t, cmd, "\n")
+	t.Log(cmd)
+}
+
+func TestLibrary_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	lua := `-- name: give_all
+-- description: give the item to all players
+-- version: 2
+-- params: prefab, count
+for _, player in ipairs(AllPlayers) do
+  -- skip ghosts
+  if not player:HasTag("playerghost") then
+    for i = 1, {{.count}} do player.components.inventory:GiveItem(SpawnPrefab({{.prefab}})) end
+  end
+end
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "give.lua"), []byte(lua), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hello.lua"), []byte(`print("hello")`), 0644))
+
+	library := NewLibrary()
+	require.NoError(t, library.LoadDir(dir))
+
+	snippet, ok := library.Get("give_all")
+	require.True(t, ok)
+	require.Equal(t, "2", snippet.Version)
+	require.Equal(t, []string{"prefab", "count"}, snippet.Params)
+
+	cmd, err := library.Render("give_all", Params{"prefab": "meat", "count": 2})
+	require.NoError(t, err)
+	require.Equal(t, `for _, player in ipairs(AllPlayers) do if not player:HasTag("playerghost") then for i = 1, 2 do player.components.inventory:GiveItem(SpawnPrefab("meat")) end end end`, cmd)
+
+	cmd, err = library.Render("hello", nil)
+	require.NoError(t, err)
+	require.Equal(t, `print("hello")`, cmd)
+
+	for _, snippet := range library.List() {
+		t.Logf("%s@%s %s", snippet.Name, snippet.Version, snippet.Description)
+	}
+}
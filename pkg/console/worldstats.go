@@ -0,0 +1,162 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DaysSurvivedBuckets is the upper bounds of the days survived distribution of WorldTrend,
+// the last bucket of the distribution counts the rest.
+var DaysSurvivedBuckets = []float64{5, 10, 20, 50, 100}
+
+// WorldStats is the statistics of the world at a moment
+type WorldStats struct {
+	Time     time.Time `json:"time"`
+	Day      int       `json:"day"`
+	Season   string    `json:"season"`
+	Entities int       `json:"entities"`
+	Players  int       `json:"players"`
+	// players dead at the moment
+	Ghosts int `json:"ghosts"`
+	// player deaths counted by the shard since the first snapshot, it starts from zero again after the shard restarted
+	Deaths int `json:"deaths"`
+	// days survived of each player online
+	DaysSurvived []float64 `json:"daysSurvived"`
+}
+
+// WorldStats queries the statistics of the world
+func (c *Console) WorldStats(ctx context.Context) (WorldStats, error) {
+	result, err := c.QuerySnippet(ctx, "world_stats", nil)
+	if err != nil {
+		return WorldStats{}, err
+	}
+	stats, err := parseWorldStats(result)
+	if err != nil {
+		return WorldStats{}, err
+	}
+	stats.Time = c.options.Clock.Now()
+	return stats, nil
+}
+
+func parseWorldStats(result string) (WorldStats, error) {
+	fields := strings.Split(result, "|")
+	if len(fields) != 6 {
+		return WorldStats{}, fmt.Errorf("world stats: unexpected result %q", result)
+	}
+
+	stats := WorldStats{Season: fields[1]}
+	var err error
+	if stats.Day, err = strconv.Atoi(fields[0]); err != nil {
+		return WorldStats{}, fmt.Errorf("world stats: unexpected day %q", fields[0])
+	}
+	if stats.Entities, err = strconv.Atoi(fields[2]); err != nil {
+		return WorldStats{}, fmt.Errorf("world stats: unexpected entities %q", fields[2])
+	}
+	if stats.Ghosts, err = strconv.Atoi(fields[3]); err != nil {
+		return WorldStats{}, fmt.Errorf("world stats: unexpected ghosts %q", fields[3])
+	}
+	if stats.Deaths, err = strconv.Atoi(fields[4]); err != nil {
+		return WorldStats{}, fmt.Errorf("world stats: unexpected deaths %q", fields[4])
+	}
+	for _, age := range strings.Split(fields[5], ",") {
+		if len(age) == 0 {
+			continue
+		}
+		days, err := strconv.ParseFloat(age, 64)
+		if err != nil {
+			return WorldStats{}, fmt.Errorf("world stats: unexpected days survived %q", age)
+		}
+		stats.DaysSurvived = append(stats.DaysSurvived, days)
+	}
+	stats.Players = len(stats.DaysSurvived)
+	return stats, nil
+}
+
+// RunWorldStats takes a snapshot of world statistics every interval and adds it into history until ctx is done,
+// a snapshot that does not finish within interval is skipped.
+func (c *Console) RunWorldStats(ctx context.Context, interval time.Duration, history *WorldStatsHistory) error {
+	return runPeriodic(ctx, c.options.Clock, "world stats", interval, c.WorldStats, &history.History)
+}
+
+// NewWorldStatsHistory return a history keeps at most size snapshots, such as 24*7*8 for hourly snapshots of 8 weeks
+func NewWorldStatsHistory(size int) *WorldStatsHistory {
+	return &WorldStatsHistory{History: History[WorldStats]{size: max(size, 1)}}
+}
+
+// WorldStatsHistory keeps the recent world statistics snapshots, see History
+type WorldStatsHistory struct {
+	History[WorldStats]
+}
+
+// WorldTrend is the aggregated world statistics of a period
+type WorldTrend struct {
+	Start   time.Time `json:"start"`
+	Samples int       `json:"samples"`
+	// the latest day and season of the period
+	Day         int     `json:"day"`
+	Season      string  `json:"season"`
+	AvgEntities float64 `json:"avgEntities"`
+	AvgPlayers  float64 `json:"avgPlayers"`
+	PeakPlayers int     `json:"peakPlayers"`
+	AvgGhosts   float64 `json:"avgGhosts"`
+	// player deaths since the previous snapshot, summed over the snapshots of the period
+	Deaths int `json:"deaths"`
+	// players sampled in each bucket of DaysSurvivedBuckets, the last one counts the rest
+	DaysSurvived []int `json:"daysSurvived"`
+	// change of AvgEntities and AvgPlayers since the previous period, zero for the first period
+	EntitiesChange float64 `json:"entitiesChange"`
+	PlayersChange  float64 `json:"playersChange"`
+}
+
+// Trends aggregates snapshots into periods in time order, periods are aligned to the zero time in UTC,
+// so weekly periods start on Monday. Periods without snapshots are omitted.
+func (h *WorldStatsHistory) Trends(period time.Duration) []WorldTrend {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var trends []WorldTrend
+	for i, stats := range h.records {
+		start := stats.Time.UTC().Truncate(period)
+		if len(trends) == 0 || !trends[len(trends)-1].Start.Equal(start) {
+			trends = append(trends, WorldTrend{Start: start, DaysSurvived: make([]int, len(DaysSurvivedBuckets)+1)})
+		}
+
+		trend := &trends[len(trends)-1]
+		trend.Samples++
+		trend.Day = stats.Day
+		trend.Season = stats.Season
+		trend.AvgEntities += float64(stats.Entities)
+		trend.AvgPlayers += float64(stats.Players)
+		trend.AvgGhosts += float64(stats.Ghosts)
+		trend.PeakPlayers = max(trend.PeakPlayers, stats.Players)
+		if i > 0 {
+			// the counter starts from zero again after the shard restarted
+			if previous := h.records[i-1].Deaths; stats.Deaths >= previous {
+				trend.Deaths += stats.Deaths - previous
+			} else {
+				trend.Deaths += stats.Deaths
+			}
+		}
+		for _, days := range stats.DaysSurvived {
+			bucket, _ := slices.BinarySearch(DaysSurvivedBuckets, days)
+			trend.DaysSurvived[bucket]++
+		}
+	}
+
+	for i := range trends {
+		trend := &trends[i]
+		samples := float64(trend.Samples)
+		trend.AvgEntities /= samples
+		trend.AvgPlayers /= samples
+		trend.AvgGhosts /= samples
+		if i > 0 {
+			trend.EntitiesChange = trend.AvgEntities - trends[i-1].AvgEntities
+			trend.PlayersChange = trend.AvgPlayers - trends[i-1].AvgPlayers
+		}
+	}
+	return trends
+}
@@ -0,0 +1,88 @@
+package console
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc/proctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsole_WorldStats(t *testing.T) {
+	start := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	clock := proctest.NewFakeClock(start)
+	p, console := newFakeConsole(t, "42|autumn|5120|1|7|3.5,41.0", WithClock(clock))
+	defer func() {
+		t.Log(p.Terminate())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := console.WorldStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 42, stats.Day)
+	require.Equal(t, "autumn", stats.Season)
+	require.Equal(t, 5120, stats.Entities)
+	require.Equal(t, 2, stats.Players)
+	require.Equal(t, 1, stats.Ghosts)
+	require.Equal(t, 7, stats.Deaths)
+	require.Equal(t, []float64{3.5, 41}, stats.DaysSurvived)
+
+	history := NewWorldStatsHistory(2)
+	runCtx, runCancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- console.RunWorldStats(runCtx, time.Hour, history)
+	}()
+	advanceRuns(clock, time.Hour, 3)
+	runCancel()
+	require.NoError(t, <-errCh)
+
+	records := history.List()
+	require.Len(t, records, 2)
+	require.Equal(t, start.Add(3*time.Hour), records[1].Time)
+	require.Error(t, console.RunWorldStats(ctx, 0, history))
+
+	_, err = parseWorldStats("42|autumn|5120")
+	require.Error(t, err)
+
+	stats, err = parseWorldStats("1|autumn|100|0|0|")
+	require.NoError(t, err)
+	require.Zero(t, stats.Players)
+}
+
+func TestWorldStatsHistory_Trends(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	// a monday
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+
+	history := NewWorldStatsHistory(10)
+	history.Add(WorldStats{Time: monday.Add(time.Hour), Day: 10, Season: "autumn", Entities: 1000, Players: 2, Deaths: 2, DaysSurvived: []float64{3, 10}})
+	history.Add(WorldStats{Time: monday.Add(48 * time.Hour), Day: 12, Season: "autumn", Entities: 2000, Players: 0, Ghosts: 0, Deaths: 5})
+	history.Add(WorldStats{Time: monday.Add(week + time.Hour), Day: 30, Season: "winter", Entities: 3000, Players: 3, Ghosts: 1, Deaths: 4, DaysSurvived: []float64{1, 60, 200}})
+
+	trends := history.Trends(week)
+	require.Len(t, trends, 2)
+	t.Logf("%+v", trends)
+
+	require.Equal(t, monday, trends[0].Start)
+	require.Equal(t, 2, trends[0].Samples)
+	require.Equal(t, 12, trends[0].Day)
+	require.Equal(t, 1500.0, trends[0].AvgEntities)
+	require.Equal(t, 1.0, trends[0].AvgPlayers)
+	require.Equal(t, 2, trends[0].PeakPlayers)
+	require.Equal(t, []int{1, 1, 0, 0, 0, 0}, trends[0].DaysSurvived)
+	require.Zero(t, trends[0].EntitiesChange)
+	require.Equal(t, 3, trends[0].Deaths)
+
+	require.Equal(t, monday.Add(week), trends[1].Start)
+	require.Equal(t, "winter", trends[1].Season)
+	require.Equal(t, 1500.0, trends[1].EntitiesChange)
+	require.Equal(t, 2.0, trends[1].PlayersChange)
+	require.Equal(t, 1.0, trends[1].AvgGhosts)
+	// the shard restarted, the counter started from zero again
+	require.Equal(t, 4, trends[1].Deaths)
+	require.Equal(t, []int{1, 0, 0, 0, 1, 1}, trends[1].DaysSurvived)
+}
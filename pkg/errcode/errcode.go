@@ -0,0 +1,88 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Code identifies a class of errors, it is stable for clients to handle errors without parsing messages
+type Code string
+
+const (
+	Unknown  Code = "ERR_UNKNOWN"
+	NotFound Code = "ERR_NOT_FOUND"
+	Timeout  Code = "ERR_TIMEOUT"
+	Canceled Code = "ERR_CANCELED"
+
+	ConsoleClosed   Code = "ERR_CONSOLE_CLOSED"
+	ConsoleNoStdout Code = "ERR_CONSOLE_NO_STDOUT"
+	InvalidCommand  Code = "ERR_INVALID_COMMAND"
+	SnippetNotFound Code = "ERR_SNIPPET_NOT_FOUND"
+	InvalidParams   Code = "ERR_INVALID_PARAMS"
+
+	PreflightFailed Code = "ERR_PREFLIGHT_FAILED"
+
+	ClusterNotFound     Code = "ERR_CLUSTER_NOT_FOUND"
+	ConfigInvalid       Code = "ERR_CONFIG_INVALID"
+	TokenInvalid        Code = "ERR_TOKEN_INVALID"
+	PortInUse           Code = "ERR_PORT_IN_USE"
+	ModMissing          Code = "ERR_MOD_MISSING"
+	ModDrift            Code = "ERR_MOD_DRIFT"
+	ModsAlreadyDisabled Code = "ERR_MODS_ALREADY_DISABLED"
+)
+
+// Error is an error with code
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error with code and text
+func New(code Code, text string) error {
+	return &Error{Code: code, Err: errors.New(text)}
+}
+
+// Errorf returns an error with code, formatted like fmt.Errorf
+func Errorf(code Code, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap attaches code to err, returns nil if err is nil
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Of returns the code of the outermost Error in the tree of err, or the code of well known errors,
+// it returns Unknown if none found, and empty if err is nil.
+func Of(err error) Code {
+	if err == nil {
+		return ""
+	}
+
+	var coded *Error
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, os.ErrNotExist):
+		return NotFound
+	default:
+		return Unknown
+	}
+}
@@ -0,0 +1,47 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOf(t *testing.T) {
+	base := errors.New("boom")
+
+	samples := []struct {
+		err  error
+		code Code
+	}{
+		{nil, ""},
+		{base, Unknown},
+		{New(ConsoleClosed, "console closed"), ConsoleClosed},
+		{fmt.Errorf("shard Master: %w", New(ConsoleClosed, "console closed")), ConsoleClosed},
+		{errors.Join(base, Wrap(ConfigInvalid, base)), ConfigInvalid},
+		{Wrap(ClusterNotFound, os.ErrNotExist), ClusterNotFound},
+		{errors.Join(Errorf(PortInUse, "port %d is in use", 10999), Errorf(ModMissing, "mods not installed")), PortInUse},
+		{fmt.Errorf("read: %w", os.ErrNotExist), NotFound},
+		{context.DeadlineExceeded, Timeout},
+		{context.Canceled, Canceled},
+	}
+
+	for _, sample := range samples {
+		require.Equal(t, sample.code, Of(sample.err), sample.err)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	require.NoError(t, Wrap(Unknown, nil))
+
+	err := Wrap(ClusterNotFound, os.ErrNotExist)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.Equal(t, os.ErrNotExist.Error(), err.Error())
+
+	err = Errorf(SnippetNotFound, "snippet not found: %s", "foo")
+	require.Equal(t, "snippet not found: foo", err.Error())
+	require.Equal(t, SnippetNotFound, Of(err))
+}
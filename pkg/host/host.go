@@ -0,0 +1,191 @@
+package host
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// Info is the report of host environment the servers run on
+type Info struct {
+	Hostname        string `json:"hostname"`
+	OS              string `json:"os"`
+	Platform        string `json:"platform"`
+	PlatformVersion string `json:"platformVersion"`
+	KernelVersion   string `json:"kernelVersion"`
+	Arch            string `json:"arch"`
+	// uptime in seconds
+	Uptime uint64 `json:"uptime"`
+
+	// virtualization system such as kvm, docker or lxc, empty on bare metal
+	Virtualization string `json:"virtualization"`
+	// guest or host
+	VirtualizationRole string `json:"virtualizationRole"`
+
+	CPU        CPUInfo         `json:"cpu"`
+	Memory     MemoryInfo      `json:"memory"`
+	Disks      []DiskInfo      `json:"disks"`
+	Interfaces []InterfaceInfo `json:"interfaces"`
+}
+
+// CPUInfo is the model and cores of cpu
+type CPUInfo struct {
+	Model         string `json:"model"`
+	PhysicalCores int    `json:"physicalCores"`
+	LogicalCores  int    `json:"logicalCores"`
+}
+
+// MemoryInfo is the memory and swap in bytes
+type MemoryInfo struct {
+	Total     uint64 `json:"total"`
+	Available uint64 `json:"available"`
+	Used      uint64 `json:"used"`
+	SwapTotal uint64 `json:"swapTotal"`
+	SwapFree  uint64 `json:"swapFree"`
+}
+
+// DiskInfo is the usage of a mounted partition in bytes
+type DiskInfo struct {
+	Device     string `json:"device"`
+	Mountpoint string `json:"mountpoint"`
+	Fstype     string `json:"fstype"`
+	Total      uint64 `json:"total"`
+	Free       uint64 `json:"free"`
+	Used       uint64 `json:"used"`
+}
+
+// InterfaceInfo is a network interface and its addresses
+type InterfaceInfo struct {
+	Name  string   `json:"name"`
+	MAC   string   `json:"mac"`
+	MTU   int      `json:"mtu"`
+	Flags []string `json:"flags"`
+	Addrs []string `json:"addrs"`
+}
+
+// SystemInfo collects the host environment, partitions whose usage can not be read are skipped
+func SystemInfo(ctx context.Context) (*Info, error) {
+	hostInfo, err := host.InfoWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("host: %w", err)
+	}
+
+	info := &Info{
+		Hostname:           hostInfo.Hostname,
+		OS:                 hostInfo.OS,
+		Platform:           hostInfo.Platform,
+		PlatformVersion:    hostInfo.PlatformVersion,
+		KernelVersion:      hostInfo.KernelVersion,
+		Arch:               hostInfo.KernelArch,
+		Uptime:             hostInfo.Uptime,
+		Virtualization:     hostInfo.VirtualizationSystem,
+		VirtualizationRole: hostInfo.VirtualizationRole,
+	}
+
+	if info.CPU, err = cpuInfo(ctx); err != nil {
+		return nil, fmt.Errorf("cpu: %w", err)
+	}
+	if info.Memory, err = memoryInfo(ctx); err != nil {
+		return nil, fmt.Errorf("memory: %w", err)
+	}
+	if info.Disks, err = diskInfo(ctx); err != nil {
+		return nil, fmt.Errorf("disk: %w", err)
+	}
+	if info.Interfaces, err = interfaceInfo(ctx); err != nil {
+		return nil, fmt.Errorf("net: %w", err)
+	}
+
+	return info, nil
+}
+
+func cpuInfo(ctx context.Context) (CPUInfo, error) {
+	var info CPUInfo
+
+	stats, err := cpu.InfoWithContext(ctx)
+	if err != nil {
+		return info, err
+	}
+	// one stat for each logical cpu on linux, they share the model
+	if len(stats) > 0 {
+		info.Model = stats[0].ModelName
+	}
+
+	if info.PhysicalCores, err = cpu.CountsWithContext(ctx, false); err != nil {
+		return info, err
+	}
+	if info.LogicalCores, err = cpu.CountsWithContext(ctx, true); err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+func memoryInfo(ctx context.Context) (MemoryInfo, error) {
+	vm, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+	swap, err := mem.SwapMemoryWithContext(ctx)
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+
+	return MemoryInfo{
+		Total:     vm.Total,
+		Available: vm.Available,
+		Used:      vm.Used,
+		SwapTotal: swap.Total,
+		SwapFree:  swap.Free,
+	}, nil
+}
+
+func diskInfo(ctx context.Context) ([]DiskInfo, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var disks []DiskInfo
+	for _, partition := range partitions {
+		usage, err := disk.UsageWithContext(ctx, partition.Mountpoint)
+		if err != nil {
+			continue
+		}
+		disks = append(disks, DiskInfo{
+			Device:     partition.Device,
+			Mountpoint: partition.Mountpoint,
+			Fstype:     partition.Fstype,
+			Total:      usage.Total,
+			Free:       usage.Free,
+			Used:       usage.Used,
+		})
+	}
+	return disks, nil
+}
+
+func interfaceInfo(ctx context.Context) ([]InterfaceInfo, error) {
+	stats, err := net.InterfacesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var interfaces []InterfaceInfo
+	for _, stat := range stats {
+		var addrs []string
+		for _, addr := range stat.Addrs {
+			addrs = append(addrs, addr.Addr)
+		}
+		interfaces = append(interfaces, InterfaceInfo{
+			Name:  stat.Name,
+			MAC:   stat.HardwareAddr,
+			MTU:   stat.MTU,
+			Flags: stat.Flags,
+			Addrs: addrs,
+		})
+	}
+	return interfaces, nil
+}
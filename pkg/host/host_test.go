@@ -0,0 +1,24 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemInfo(t *testing.T) {
+	info, err := SystemInfo(context.Background())
+	require.NoError(t, err)
+
+	require.NotEmpty(t, info.Hostname)
+	require.NotEmpty(t, info.OS)
+	require.Positive(t, info.CPU.LogicalCores)
+	require.Positive(t, info.Memory.Total)
+	require.NotEmpty(t, info.Interfaces)
+
+	bs, err := json.MarshalIndent(info, "", "  ")
+	require.NoError(t, err)
+	t.Log(string(bs))
+}
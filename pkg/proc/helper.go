@@ -37,6 +37,20 @@ func (c *Channel[T]) Send(v T) {
 	c.ch <- v
 }
 
+// SendContext sends v unless ctx is done, returns false if v is not sent
+func (c *Channel[T]) SendContext(ctx context.Context, v T) bool {
+	if c.closed.Load() {
+		return false
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case c.ch <- v:
+		return true
+	}
+}
+
 func (c *Channel[T]) TryRecv() (T, bool) {
 	var v T
 	if c.closed.Load() {
@@ -64,6 +78,24 @@ func (c *Channel[T]) Recv() (T, bool) {
 	return rVal, true
 }
 
+// RecvContext receives a value unless ctx is done, returns false if nothing is received
+func (c *Channel[T]) RecvContext(ctx context.Context) (T, bool) {
+	var v T
+	if c.closed.Load() {
+		return v, false
+	}
+
+	select {
+	case <-ctx.Done():
+		return v, false
+	case rVal, ok := <-c.ch:
+		if !ok {
+			return v, false
+		}
+		return rVal, true
+	}
+}
+
 func (c *Channel[T]) Closed() bool {
 	return c.closed.Load()
 }
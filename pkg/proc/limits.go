@@ -0,0 +1,157 @@
+package proc
+
+import (
+	"bufio"
+	"bytes"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultMaxLineSize    = 512 * 1024
+	DefaultWorkers        = 20
+	DefaultDeliverTimeout = time.Second * 20
+)
+
+// Limits caps the memory used to buffer stdout and stderr lines, zero fields take the defaults
+type Limits struct {
+	// max size of a line, default DefaultMaxLineSize, the scanner of each output grows up to it
+	MaxLineSize int
+	// truncate lines longer than MaxLineSize instead of stopping the output stream, see Line.Truncated
+	TruncateLines bool
+	// number of workers delivering lines to streams, default DefaultWorkers
+	Workers int
+	// buffered lines of each named stream, default 0, lines in stream buffers are not accounted
+	StreamBuffer int
+	// max bytes of lines waiting for delivery, lines exceeding the budget are dropped, 0 means unlimited
+	MemoryBudget int64
+	// how long a line waits for its stream to receive before it is dropped, and how long Wait waits for
+	// the output read to the end after the process exited, default DefaultDeliverTimeout
+	DeliverTimeout time.Duration
+}
+
+// BoundedLimits returns the limits keep buffering of stdout and stderr within budget bytes,
+// for hosts with little memory, budget should be at least 64KiB.
+func BoundedLimits(budget int64) Limits {
+	maxLineSize := int(min(budget/8, 64*1024))
+	return Limits{
+		MaxLineSize:   maxLineSize,
+		TruncateLines: true,
+		Workers:       4,
+		// unbuffered streams, so lines are accounted until they are received
+		StreamBuffer: 0,
+		// the rest after two scanner buffers
+		MemoryBudget: budget - 2*int64(maxLineSize),
+	}
+}
+
+// lineTruncator splits lines like bufio.ScanLines, except lines longer than maxLineSize are truncated
+// to maxLineSize and the rest of them are discarded, the buffer of scanner should hold maxLineSize+2 bytes,
+// so a line of exactly maxLineSize ending with \r\n is not truncated.
+type lineTruncator struct {
+	maxLineSize int
+	discarding  bool
+	// whether the last token was truncated
+	truncated bool
+}
+
+func (s *lineTruncator) scan(data []byte, atEOF bool) (int, []byte, error) {
+	s.truncated = false
+	if s.discarding {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			s.discarding = false
+			return i + 1, nil, nil
+		}
+		return len(data), nil, nil
+	}
+
+	advance, token, err := bufio.ScanLines(data, atEOF)
+	if len(token) > s.maxLineSize {
+		s.truncated = true
+		return advance, token[:s.maxLineSize], err
+	}
+	if advance > 0 || token != nil || err != nil || len(data) < s.maxLineSize+2 {
+		return advance, token, err
+	}
+
+	// the buffer is full without a line end
+	s.discarding = true
+	s.truncated = true
+	return len(data), data[:s.maxLineSize], nil
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxLineSize <= 0 {
+		l.MaxLineSize = DefaultMaxLineSize
+	}
+	if l.Workers <= 0 {
+		l.Workers = DefaultWorkers
+	}
+	if l.StreamBuffer < 0 {
+		l.StreamBuffer = 0
+	}
+	if l.DeliverTimeout <= 0 {
+		l.DeliverTimeout = DefaultDeliverTimeout
+	}
+	return l
+}
+
+// BufferStats is the accounting of lines buffered for delivery
+type BufferStats struct {
+	// bytes of lines waiting for delivery
+	Pending int64
+	// max Pending ever reached
+	PeakPending int64
+	// lines dropped by memory budget, delivery timeout or Wait giving up delivery
+	Dropped int64
+}
+
+// bufferAccount counts bytes of lines pending delivery against budget
+type bufferAccount struct {
+	budget  int64
+	pending atomic.Int64
+	peak    atomic.Int64
+	dropped atomic.Int64
+}
+
+// reserve accounts n bytes, returns false and counts a drop if it exceeds budget
+func (a *bufferAccount) reserve(n int) bool {
+	for {
+		pending := a.pending.Load()
+		if a.budget > 0 && pending+int64(n) > a.budget {
+			a.dropped.Add(1)
+			return false
+		}
+		if a.pending.CompareAndSwap(pending, pending+int64(n)) {
+			a.updatePeak(pending + int64(n))
+			return true
+		}
+	}
+}
+
+// release returns n bytes reserved before
+func (a *bufferAccount) release(n int) {
+	a.pending.Add(-int64(n))
+}
+
+func (a *bufferAccount) updatePeak(pending int64) {
+	for {
+		peak := a.peak.Load()
+		if pending <= peak || a.peak.CompareAndSwap(peak, pending) {
+			return
+		}
+	}
+}
+
+func (a *bufferAccount) stats() BufferStats {
+	return BufferStats{
+		Pending:     a.pending.Load(),
+		PeakPending: a.peak.Load(),
+		Dropped:     a.dropped.Load(),
+	}
+}
+
+// BufferStats returns the accounting of stdout and stderr lines buffered for delivery
+func (p *Proc) BufferStats() BufferStats {
+	return p.buffers.stats()
+}
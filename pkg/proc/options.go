@@ -20,8 +20,21 @@ type Options struct {
 
 	// max wait time before stop the process
 	MaxWaitTime time.Duration
+
+	// middlewares applied to every stdout and stderr line in order
+	Middlewares []OutputMiddleware
+
+	// limits of stdout and stderr buffering
+	Limits Limits
+
+	// host resources checked before start, nil to skip
+	Preflight *Preflight
 }
 
+// OutputMiddleware transform a line read from stdout or stderr before it is sent to streams,
+// return nil to drop the line.
+type OutputMiddleware func(line []byte) []byte
+
 // Option apply option into *Options
 type Option func(*Options)
 
@@ -71,3 +84,21 @@ func WithMaxWaitTime(t time.Duration) Option {
 		opt.MaxWaitTime = t
 	}
 }
+
+func WithOutputMiddleware(middlewares ...OutputMiddleware) Option {
+	return func(opt *Options) {
+		opt.Middlewares = append(opt.Middlewares, middlewares...)
+	}
+}
+
+func WithLimits(limits Limits) Option {
+	return func(opt *Options) {
+		opt.Limits = limits
+	}
+}
+
+func WithPreflight(preflight Preflight) Option {
+	return func(opt *Options) {
+		opt.Preflight = &preflight
+	}
+}
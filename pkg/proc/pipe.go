@@ -2,14 +2,28 @@ package proc
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"sync/atomic"
 	"time"
 )
 
 type Stream = Channel[[]byte]
 
+// Line is a line of stdout or stderr
+type Line struct {
+	Data []byte
+	// the line is longer than Limits.MaxLineSize and cut to it, only with Limits.TruncateLines
+	Truncated bool
+}
+
+// LineStream is a stream of lines which tells truncated lines apart
+type LineStream = Channel[Line]
+
 // StdinPipe return a named stream pipe with stdin
 func (p *Proc) StdinPipe(name string) *Stream {
 	if p.PID() != -1 {
@@ -20,7 +34,7 @@ func (p *Proc) StdinPipe(name string) *Stream {
 		return nil
 	}
 
-	ch := MakeChannel[[]byte](0)
+	ch := MakeChannel[[]byte](p.options.Limits.StreamBuffer)
 	p.stdinChs[name] = ch
 
 	return ch
@@ -36,7 +50,7 @@ func (p *Proc) StdoutPipe(name string) *Stream {
 		return nil
 	}
 
-	ch := MakeChannel[[]byte](0)
+	ch := MakeChannel[[]byte](p.options.Limits.StreamBuffer)
 	p.stdoutChs[name] = ch
 
 	return ch
@@ -52,12 +66,44 @@ func (p *Proc) StderrPipe(name string) *Stream {
 		return nil
 	}
 
-	ch := MakeChannel[[]byte](0)
+	ch := MakeChannel[[]byte](p.options.Limits.StreamBuffer)
 	p.stderrChs[name] = ch
 
 	return ch
 }
 
+// StdoutLines return a named line stream with stdout
+func (p *Proc) StdoutLines(name string) *LineStream {
+	if p.PID() != -1 {
+		panic(fmt.Sprintf("bind pipe after process started: %s", name))
+	}
+
+	if !p.options.Stdout {
+		return nil
+	}
+
+	ch := MakeChannel[Line](p.options.Limits.StreamBuffer)
+	p.stdoutLineChs[name] = ch
+
+	return ch
+}
+
+// StderrLines return a named line stream with stderr
+func (p *Proc) StderrLines(name string) *LineStream {
+	if p.PID() != -1 {
+		panic(fmt.Sprintf("bind pipe after process started: %s", name))
+	}
+
+	if !p.options.Stderr {
+		return nil
+	}
+
+	ch := MakeChannel[Line](p.options.Limits.StreamBuffer)
+	p.stderrLineChs[name] = ch
+
+	return ch
+}
+
 func (p *Proc) listenStdinPipe(ctx context.Context) {
 	if !p.options.Stdin {
 		return
@@ -93,7 +139,7 @@ func (p *Proc) listenStdoutPipe(ctx context.Context) {
 		return
 	}
 
-	p.listenOutStream(ctx, p.stdoutPipe, p.stdoutChs)
+	p.listenOutStream(ctx, p.stdoutPipe, p.stdoutChs, p.stdoutLineChs)
 }
 
 func (p *Proc) listenStderrPipe(ctx context.Context) {
@@ -101,45 +147,165 @@ func (p *Proc) listenStderrPipe(ctx context.Context) {
 		return
 	}
 
-	p.listenOutStream(ctx, p.stderrPipe, p.stderrChs)
+	p.listenOutStream(ctx, p.stderrPipe, p.stderrChs, p.stderrLineChs)
+}
+
+// streamQueueSize is the max lines queued for delivery of each stream, the output is not read while a queue
+// is full, so a slow stream holds back the reading instead of the pending lines growing without bound. Lines
+// queued for a stream are delivered in order, and each waits at most Limits.DeliverTimeout for the stream.
+const streamQueueSize = 64
+
+// outStream delivers lines to a stream or a line stream in order, by at most one worker at a time
+type outStream struct {
+	name    string
+	stream  *Stream
+	lines   *LineStream
+	queue   chan Line
+	running atomic.Bool
+}
+
+// channels returns the channels to send a line to, the one not bound is nil, so it is never selected
+func (o *outStream) channels() (chan []byte, chan Line) {
+	if o.stream != nil {
+		return o.stream.ch, nil
+	}
+	return nil, o.lines.ch
 }
 
-func (p *Proc) listenOutStream(ctx context.Context, readCloser io.ReadCloser, readChs map[string]*Stream) {
+func (p *Proc) listenOutStream(ctx context.Context, readCloser io.ReadCloser, readChs map[string]*Stream, lineChs map[string]*LineStream) {
+	outStreams := make([]*outStream, 0, len(readChs)+len(lineChs))
+	for name, readCh := range readChs {
+		outStreams = append(outStreams, &outStream{name: name, stream: readCh, queue: make(chan Line, streamQueueSize)})
+	}
+	for name, lineCh := range lineChs {
+		outStreams = append(outStreams, &outStream{name: name, lines: lineCh, queue: make(chan Line, streamQueueSize)})
+	}
+
+	p.readers.Add(1)
 	p.group.Go(func() error {
+		defer p.readers.Done()
+
 		scanner := bufio.NewScanner(readCloser)
-		scanner.Buffer(make([]byte, 256*1024), 512*1024)
+		maxLineSize := p.options.Limits.MaxLineSize
+		var truncator *lineTruncator
+		if p.options.Limits.TruncateLines {
+			// room for the line end, so a line of exactly maxLineSize is not truncated
+			truncator = &lineTruncator{maxLineSize: maxLineSize}
+			scanner.Buffer(make([]byte, min(64*1024, maxLineSize+2)), maxLineSize+2)
+			scanner.Split(truncator.scan)
+		} else {
+			scanner.Buffer(make([]byte, min(64*1024, maxLineSize)), maxLineSize)
+		}
 
 		for scanner.Scan() {
 			if done, err := isCtxDone(ctx); done {
 				return err
 			}
 
-			bs := scanner.Bytes()
-
-			for name, readCh := range readChs {
-				// submit into work pool
-				err := p.workerPool.Submit(func() {
-					// copy bytes to keep mem safe
-					buffer := p.bufferPool.Get()
-					defer p.bufferPool.Put(buffer)
-					buffer.Reset()
+			bs := p.applyMiddlewares(scanner.Bytes())
+			if bs == nil {
+				continue
+			}
 
-					_, _ = buffer.Write(bs)
+			truncated := truncator != nil && truncator.truncated
+			for _, out := range outStreams {
+				// copy bytes for every stream, the scanner reuses its buffer and the stream owns what it received
+				line := Line{Data: bytes.Clone(bs), Truncated: truncated}
+				if !p.buffers.reserve(len(line.Data)) {
+					continue
+				}
 
-					select {
-					case <-ctx.Done():
-					case <-time.After(time.Second * 20):
-					case readCh.ch <- buffer.Bytes():
-					}
-					return
-				})
+				if !p.beginDelivery() {
+					p.buffers.dropped.Add(1)
+					p.buffers.release(len(line.Data))
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					p.buffers.release(len(line.Data))
+					p.delivering.Done()
+					return ctx.Err()
+				case <-p.discard.Done():
+					p.buffers.dropped.Add(1)
+					p.buffers.release(len(line.Data))
+					p.delivering.Done()
+					continue
+				case out.queue <- line:
+				}
 
-				if err != nil {
-					return fmt.Errorf("%s: %w", name, err)
+				if err := p.deliver(ctx, out); err != nil {
+					return fmt.Errorf("%s: %w", out.name, err)
 				}
 			}
 		}
 
-		return scanner.Err()
+		// Wait reads the output to the end before closing the pipe, so os.ErrClosed means it was closed by
+		// Terminate or Kill while reading, or by Wait after it gave up reading, such as the output is held
+		// open by a grandchild
+		err := scanner.Err()
+		if errors.Is(err, os.ErrClosed) && p.discard.Err() != nil {
+			return nil
+		}
+		return err
+	})
+}
+
+// beginDelivery accounts a line queued for delivery, it returns false after Wait gave up delivery
+func (p *Proc) beginDelivery() bool {
+	p.discardMu.Lock()
+	defer p.discardMu.Unlock()
+
+	if p.discard.Err() != nil {
+		return false
+	}
+	p.delivering.Add(1)
+	return true
+}
+
+// deliver submits a worker sending queued lines of out to its stream, unless one is running already
+func (p *Proc) deliver(ctx context.Context, out *outStream) error {
+	if !out.running.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	// submitting blocks when all workers are busy, instead of failing the output stream
+	return p.workerPool.Submit(func() {
+		for {
+			select {
+			case line := <-out.queue:
+				streamCh, lineCh := out.channels()
+				select {
+				case <-ctx.Done():
+					p.buffers.dropped.Add(1)
+				case <-p.discard.Done():
+					p.buffers.dropped.Add(1)
+				case <-time.After(p.options.Limits.DeliverTimeout):
+					p.buffers.dropped.Add(1)
+				case streamCh <- line.Data:
+				case lineCh <- line:
+				}
+				p.buffers.release(len(line.Data))
+				p.delivering.Done()
+				continue
+			default:
+			}
+
+			out.running.Store(false)
+			// a line may be queued after the queue was seen empty, but before running was reset
+			if len(out.queue) == 0 || !out.running.CompareAndSwap(false, true) {
+				return
+			}
+		}
 	})
 }
+
+// applyMiddlewares pass line through all output middlewares, return nil if the line is dropped
+func (p *Proc) applyMiddlewares(line []byte) []byte {
+	for _, middleware := range p.options.Middlewares {
+		line = middleware(line)
+		if line == nil {
+			return nil
+		}
+	}
+	return line
+}
@@ -1,8 +1,14 @@
 package proc
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -101,3 +107,381 @@ done
 	<-stdoutDone
 	<-stdinDone
 }
+
+func TestProc_Limits(t *testing.T) {
+	for _, limits := range []Limits{
+		{},
+		{MaxLineSize: 4096, Workers: 4, MemoryBudget: 1500},
+	} {
+		t.Run(fmt.Sprintf("budget %d", limits.MemoryBudget), func(t *testing.T) {
+			proc, err := NewProc(
+				context.Background(),
+				WithCommand("bash", "-c", `for i in $(seq 100); do printf "%01000d\n" $i; done`),
+				WithStdout(),
+				WithLimits(limits),
+			)
+			require.NoError(t, err)
+
+			pipe := proc.StdoutPipe("out")
+
+			var received int64
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for {
+					recv, ok := pipe.Recv()
+					if !ok {
+						return
+					}
+					require.Len(t, recv, 1000)
+					received++
+					time.Sleep(time.Millisecond)
+				}
+			}()
+
+			require.NoError(t, proc.Start())
+			require.NoError(t, proc.Wait())
+			<-done
+
+			stats := proc.BufferStats()
+			t.Log(received, stats)
+			require.Zero(t, stats.Pending)
+			require.Equal(t, int64(100), received+stats.Dropped)
+			if limits.MemoryBudget > 0 {
+				require.NotZero(t, stats.Dropped)
+				require.LessOrEqual(t, stats.PeakPending, limits.MemoryBudget)
+			} else {
+				require.Zero(t, stats.Dropped)
+			}
+		})
+	}
+}
+
+func TestProc_OutStreamOrder(t *testing.T) {
+	proc, err := NewProc(
+		context.Background(),
+		WithCommand("seq", "1000"),
+		WithStdout(),
+		WithLimits(Limits{Workers: 2}),
+	)
+	require.NoError(t, err)
+
+	var (
+		group    sync.WaitGroup
+		received [3][]string
+	)
+	for i := range received {
+		pipe := proc.StdoutPipe(fmt.Sprintf("out%d", i))
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for {
+				recv, ok := pipe.Recv()
+				if !ok {
+					return
+				}
+				received[i] = append(received[i], string(recv))
+			}
+		}()
+	}
+
+	require.NoError(t, proc.Start())
+	require.NoError(t, proc.Wait())
+	group.Wait()
+
+	var expect []string
+	for i := 1; i <= 1000; i++ {
+		expect = append(expect, strconv.Itoa(i))
+	}
+	for _, lines := range received {
+		require.Equal(t, expect, lines)
+	}
+}
+
+func TestProc_OutStreamCopy(t *testing.T) {
+	proc, err := NewProc(
+		context.Background(),
+		WithCommand("seq", "100"),
+		WithStdout(),
+	)
+	require.NoError(t, err)
+
+	var (
+		group    sync.WaitGroup
+		received [2][]string
+	)
+	for i := range received {
+		pipe := proc.StdoutPipe(fmt.Sprintf("out%d", i))
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for {
+				recv, ok := pipe.Recv()
+				if !ok {
+					return
+				}
+				received[i] = append(received[i], string(recv))
+				// the stream owns what it received, overwriting must not affect other streams
+				for j := range recv {
+					recv[j] = 'x'
+				}
+			}
+		}()
+	}
+
+	require.NoError(t, proc.Start())
+	require.NoError(t, proc.Wait())
+	group.Wait()
+
+	require.Len(t, received[0], 100)
+	require.Equal(t, received[0], received[1])
+	require.Equal(t, "100", received[0][99])
+}
+
+func TestProc_BlockingPool(t *testing.T) {
+	const streams = 8
+
+	proc, err := NewProc(
+		context.Background(),
+		WithCommand("seq", "500"),
+		WithStdout(),
+		WithLimits(Limits{Workers: 1}),
+	)
+	require.NoError(t, err)
+
+	var (
+		group    sync.WaitGroup
+		received [streams]int
+	)
+	for i := range streams {
+		pipe := proc.StdoutPipe(fmt.Sprintf("out%d", i))
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for {
+				if _, ok := pipe.Recv(); !ok {
+					return
+				}
+				received[i]++
+			}
+		}()
+	}
+
+	// with one worker for all streams, submitting waits for the worker instead of failing
+	require.NoError(t, proc.Start())
+	require.NoError(t, proc.Wait())
+	group.Wait()
+
+	for _, n := range received {
+		require.Equal(t, 500, n)
+	}
+	require.Zero(t, proc.BufferStats().Dropped)
+}
+
+func TestProc_WaitUnreadStream(t *testing.T) {
+	proc, err := NewProc(
+		context.Background(),
+		WithCommand("bash", "-c", "seq 68"),
+		WithStdout(),
+		WithLimits(Limits{DeliverTimeout: time.Millisecond * 100}),
+	)
+	require.NoError(t, err)
+
+	// a stream nobody reads
+	proc.StdoutPipe("unread")
+
+	require.NoError(t, proc.Start())
+	start := time.Now()
+	require.NoError(t, proc.Wait())
+	t.Log(time.Since(start), proc.BufferStats())
+	require.Less(t, time.Since(start), time.Second*2)
+	require.Equal(t, int64(68), proc.BufferStats().Dropped)
+	require.Zero(t, proc.BufferStats().Pending)
+}
+
+func TestProc_WaitOutputHeldOpen(t *testing.T) {
+	proc, err := NewProc(
+		context.Background(),
+		WithCommand("bash", "-c", "echo ready; sleep 3 &"),
+		WithStdout(),
+		WithLimits(Limits{DeliverTimeout: time.Millisecond * 100}),
+	)
+	require.NoError(t, err)
+
+	pipe := proc.StdoutPipe("out")
+	received := make(chan string, 1)
+	go func() {
+		recv, _ := pipe.Recv()
+		received <- string(recv)
+	}()
+
+	// the grandchild keeps stdout open after the process exited
+	require.NoError(t, proc.Start())
+	start := time.Now()
+	require.NoError(t, proc.Wait())
+	t.Log(time.Since(start))
+	require.Less(t, time.Since(start), time.Second*2)
+	require.Equal(t, "ready", <-received)
+}
+
+func TestProc_TerminateWhileReading(t *testing.T) {
+	proc, err := NewProc(
+		context.Background(),
+		WithCommand("bash", "-c", `echo ready; sleep 10`),
+		WithStdout(),
+	)
+	require.NoError(t, err)
+
+	pipe := proc.StdoutPipe("out")
+
+	require.NoError(t, proc.Start())
+	recv, ok := pipe.Recv()
+	require.True(t, ok)
+	require.Equal(t, "ready", string(recv))
+
+	// the pipe is closed while the output is read
+	err = proc.Terminate()
+	t.Log(err)
+	require.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestBoundedLimits(t *testing.T) {
+	limits := BoundedLimits(1024 * 1024)
+	require.Equal(t, 64*1024, limits.MaxLineSize)
+	require.Equal(t, int64(1024*1024-128*1024), limits.MemoryBudget)
+
+	limits = BoundedLimits(64 * 1024)
+	require.Equal(t, 8*1024, limits.MaxLineSize)
+	require.Equal(t, int64(48*1024), limits.MemoryBudget)
+}
+
+// benchmarkOutStream measures lines passing through listenOutStream to subscribers
+func benchmarkOutStream(b *testing.B, subscribers int, options ...Option) {
+	proc, err := NewProc(context.Background(), append([]Option{WithCommand("true"), WithStdout()}, options...)...)
+	require.NoError(b, err)
+	defer proc.workerPool.Release()
+	defer proc.cancel()
+
+	var received sync.WaitGroup
+	streams := make(map[string]*Stream)
+	for i := range subscribers {
+		stream := MakeChannel[[]byte](proc.options.Limits.StreamBuffer)
+		streams[fmt.Sprintf("sub%d", i)] = stream
+
+		received.Add(1)
+		go func() {
+			defer received.Done()
+			for range b.N {
+				stream.Recv()
+			}
+		}()
+	}
+
+	line := "[00:01:23]: " + strings.Repeat("x", 100) + "\n"
+	reader := io.NopCloser(strings.NewReader(strings.Repeat(line, b.N)))
+
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	proc.listenOutStream(proc.ctx, reader, streams, nil)
+	received.Wait()
+
+	b.StopTimer()
+	// wait of group cancels the context, so it goes after all lines received
+	require.NoError(b, proc.group.Wait())
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "lines/s")
+}
+
+func BenchmarkProc_OutStream(b *testing.B) {
+	for _, subscribers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("subscribers %d", subscribers), func(b *testing.B) {
+			benchmarkOutStream(b, subscribers)
+		})
+	}
+
+	b.Run("bounded", func(b *testing.B) {
+		benchmarkOutStream(b, 4, WithLimits(BoundedLimits(1024*1024)))
+	})
+}
+
+// TestProc_OutStreamAllocs guards the allocations per line of every subscriber, run benchmarks for the throughput
+func TestProc_OutStreamAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip benchmark in short mode")
+	}
+
+	const subscribers = 4
+	result := testing.Benchmark(func(b *testing.B) {
+		benchmarkOutStream(b, subscribers)
+	})
+	t.Log(result, result.MemString())
+
+	require.LessOrEqual(t, result.AllocsPerOp(), int64(6*subscribers))
+}
+
+func TestLineTruncator(t *testing.T) {
+	input := "short\r\n01234567\n01234567\r\n012345678\n0123456789abcdef\nline\n" + strings.Repeat("x", 20) + "\n123456789"
+	truncator := &lineTruncator{maxLineSize: 8}
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	scanner.Buffer(make([]byte, 4), 8+2)
+	scanner.Split(truncator.scan)
+
+	var lines []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if truncator.truncated {
+			line += " (truncated)"
+		}
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []string{
+		"short",
+		// lines of exactly max size are complete
+		"01234567",
+		"01234567",
+		"01234567 (truncated)",
+		"01234567 (truncated)",
+		"line",
+		"xxxxxxxx (truncated)",
+		"12345678 (truncated)",
+	}, lines)
+}
+
+func TestProc_TruncateLines(t *testing.T) {
+	proc, err := NewProc(
+		context.Background(),
+		WithCommand("bash", "-c", `echo first; head -c 100000 /dev/zero | tr '\0' x; echo; head -c 4096 /dev/zero | tr '\0' y; echo; echo last`),
+		WithStdout(),
+		WithLimits(Limits{MaxLineSize: 4096, TruncateLines: true}),
+	)
+	require.NoError(t, err)
+
+	pipe := proc.StdoutLines("out")
+
+	var lines []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			recv, ok := pipe.Recv()
+			if !ok {
+				return
+			}
+			if recv.Truncated {
+				lines = append(lines, fmt.Sprintf("truncated %d", len(recv.Data)))
+			} else if len(recv.Data) > 8 {
+				lines = append(lines, fmt.Sprintf("complete %d", len(recv.Data)))
+			} else {
+				lines = append(lines, string(recv.Data))
+			}
+		}
+	}()
+
+	require.NoError(t, proc.Start())
+	require.NoError(t, proc.Wait())
+	<-done
+
+	require.Equal(t, []string{"first", "truncated 4096", "complete 4096", "last"}, lines)
+}
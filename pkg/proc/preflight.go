@@ -0,0 +1,106 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/errcode"
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// ErrPreflight is wrapped by errors of failed preflight checks
+var ErrPreflight = errcode.New(errcode.PreflightFailed, "preflight")
+
+// Preflight is the host resources required before starting the process, zero fields are not checked
+type Preflight struct {
+	// min available memory in bytes
+	MinMemory uint64
+	// min free space in bytes of the disk DiskPath is on, default the work directory
+	MinDisk  uint64
+	DiskPath string
+	// max cpu usage in percent of the host, sampled over CPUSample, default 1s
+	MaxCPUPercent float64
+	CPUSample     time.Duration
+
+	// start the process anyway, failed checks are passed to Warn
+	WarnOnly bool
+	Warn     func(err error)
+}
+
+// Check checks the host resources, returns the failed checks joined, each wraps ErrPreflight
+func (p Preflight) Check(ctx context.Context) error {
+	var errs []error
+
+	if p.MinMemory > 0 {
+		vm, err := mem.VirtualMemoryWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		if vm.Available < p.MinMemory {
+			errs = append(errs, fmt.Errorf("%w: available memory %d bytes, requires %d", ErrPreflight, vm.Available, p.MinMemory))
+		}
+	}
+
+	if p.MinDisk > 0 {
+		path := p.DiskPath
+		if len(path) == 0 {
+			wd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			path = wd
+		}
+
+		usage, err := disk.UsageWithContext(ctx, path)
+		if err != nil {
+			return err
+		}
+		if usage.Free < p.MinDisk {
+			errs = append(errs, fmt.Errorf("%w: free disk of %s %d bytes, requires %d", ErrPreflight, path, usage.Free, p.MinDisk))
+		}
+	}
+
+	if p.MaxCPUPercent > 0 {
+		sample := p.CPUSample
+		if sample <= 0 {
+			sample = time.Second
+		}
+
+		percents, err := cpu.PercentWithContext(ctx, sample, false)
+		if err != nil {
+			return err
+		}
+		if len(percents) > 0 && percents[0] > p.MaxCPUPercent {
+			errs = append(errs, fmt.Errorf("%w: cpu usage %.1f%%, requires at most %.1f%%", ErrPreflight, percents[0], p.MaxCPUPercent))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// preflight runs the preflight checks of options, returns error if the process should not start
+func (p *Proc) preflight() error {
+	if p.options.Preflight == nil {
+		return nil
+	}
+
+	preflight := *p.options.Preflight
+	if len(preflight.DiskPath) == 0 && len(p.options.WorkDir) > 0 {
+		preflight.DiskPath = p.options.WorkDir
+	}
+
+	err := preflight.Check(p.ctx)
+	if err == nil || !errors.Is(err, ErrPreflight) || !preflight.WarnOnly {
+		return err
+	}
+
+	if preflight.Warn != nil {
+		preflight.Warn(err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package proc
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreflight_Check(t *testing.T) {
+	ctx := context.Background()
+
+	preflight := Preflight{MinMemory: 1, MinDisk: 1, DiskPath: t.TempDir(), MaxCPUPercent: 100, CPUSample: 100 * time.Millisecond}
+	require.NoError(t, preflight.Check(ctx))
+
+	preflight = Preflight{MinMemory: math.MaxUint64, MinDisk: math.MaxUint64}
+	err := preflight.Check(ctx)
+	require.ErrorIs(t, err, ErrPreflight)
+	require.ErrorContains(t, err, "memory")
+	require.ErrorContains(t, err, "disk")
+	t.Log(err)
+}
+
+func TestProc_Preflight(t *testing.T) {
+	ctx := context.Background()
+
+	proc, err := NewProc(ctx, WithCommand("true"), WithPreflight(Preflight{MinMemory: math.MaxUint64}))
+	require.NoError(t, err)
+	require.ErrorIs(t, proc.Start(), ErrPreflight)
+	require.Equal(t, -1, proc.PID())
+
+	var warning error
+	proc, err = NewProc(ctx, WithCommand("true"), WithPreflight(Preflight{
+		MinMemory: math.MaxUint64,
+		WarnOnly:  true,
+		Warn: func(err error) {
+			warning = err
+		},
+	}))
+	require.NoError(t, err)
+	require.NoError(t, proc.Start())
+	require.NoError(t, proc.Wait())
+	require.ErrorIs(t, warning, ErrPreflight)
+}
@@ -3,16 +3,18 @@ package proc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"runtime"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/panjf2000/ants/v2"
+	"github.com/shirou/gopsutil/v4/net"
 	"github.com/shirou/gopsutil/v4/process"
-	"github.com/valyala/bytebufferpool"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -22,9 +24,14 @@ func NewProc(ctx context.Context, procOptions ...Option) (*Proc, error) {
 	for _, opt := range procOptions {
 		opt(&opts)
 	}
+	opts.Limits = opts.Limits.withDefaults()
 
 	procCmd := exec.CommandContext(ctx, opts.Name, opts.Args...)
+	// pipes inherited by a grandchild must not block cmd.Wait after the process exited
+	procCmd.WaitDelay = opts.Limits.DeliverTimeout
 	newProc := &Proc{cmd: procCmd, options: opts}
+	newProc.buffers.budget = opts.Limits.MemoryBudget
+	newProc.discard, newProc.discardRest = context.WithCancel(context.Background())
 
 	if len(opts.WorkDir) > 0 {
 		procCmd.Dir = opts.WorkDir
@@ -49,6 +56,7 @@ func NewProc(ctx context.Context, procOptions ...Option) (*Proc, error) {
 		}
 		newProc.stdoutPipe = stdout
 		newProc.stdoutChs = make(map[string]*Stream)
+		newProc.stdoutLineChs = make(map[string]*LineStream)
 	}
 
 	if opts.Stderr {
@@ -58,13 +66,15 @@ func NewProc(ctx context.Context, procOptions ...Option) (*Proc, error) {
 		}
 		newProc.stderrPipe = stderr
 		newProc.stderrChs = make(map[string]*Stream)
+		newProc.stderrLineChs = make(map[string]*LineStream)
 	}
 
 	group, groupCtx := errgroup.WithContext(ctx)
 	newProc.group = group
 	newProc.ctx = groupCtx
 
-	workerPool, err := ants.NewPool(20, ants.WithNonblocking(true))
+	// submitting blocks when all workers are busy, instead of failing the output stream
+	workerPool, err := ants.NewPool(opts.Limits.Workers)
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +91,23 @@ func NewProc(ctx context.Context, procOptions ...Option) (*Proc, error) {
 	return newProc, nil
 }
 
+// Runner is the lifecycle and pipes of a process, it is implemented by *Proc,
+// and by proctest.Runner for tests without spawning real processes.
+type Runner interface {
+	Start() error
+	Wait() error
+	Signal(signal syscall.Signal) error
+	Terminate() error
+	Kill() error
+	PID() int
+	ExitCode() int
+	StdinPipe(name string) *Stream
+	StdoutPipe(name string) *Stream
+	StderrPipe(name string) *Stream
+}
+
+var _ Runner = (*Proc)(nil)
+
 // Proc represent a child process of dontstarve
 type Proc struct {
 	ctx    context.Context
@@ -103,18 +130,28 @@ type Proc struct {
 	stdinPipe io.WriteCloser
 	stdinChs  map[string]*Stream
 
-	stdoutPipe io.ReadCloser
-	stdoutChs  map[string]*Stream
+	stdoutPipe    io.ReadCloser
+	stdoutChs     map[string]*Stream
+	stdoutLineChs map[string]*LineStream
 
-	stderrPipe io.ReadCloser
-	stderrChs  map[string]*Stream
+	stderrPipe    io.ReadCloser
+	stderrChs     map[string]*Stream
+	stderrLineChs map[string]*LineStream
 
 	// group and pool
 	group      *errgroup.Group
 	workerPool *ants.Pool
-	bufferPool bytebufferpool.Pool
+	buffers    bufferAccount
 	once       sync.Once
 
+	// readers of stdout and stderr, and lines queued but not delivered to streams yet
+	readers    sync.WaitGroup
+	delivering sync.WaitGroup
+	// done after Wait gave up delivery, the lines not delivered yet are dropped
+	discardMu   sync.Mutex
+	discard     context.Context
+	discardRest context.CancelFunc
+
 	options Options
 }
 
@@ -137,6 +174,10 @@ func (p *Proc) start() error {
 
 // Start starts the process but does not wait for it to complete.
 func (p *Proc) Start() error {
+	if err := p.preflight(); err != nil {
+		return err
+	}
+
 	// start the process
 	err := p.cmd.Start()
 	if err != nil {
@@ -151,6 +192,9 @@ func (p *Proc) Start() error {
 // Wait waits for the process to exit and waits for any copying to
 // stdin or copying from stdout or stderr to complete.
 func (p *Proc) Wait() error {
+	// cmd.Wait closes the pipes after the process exited, read the output to the end before
+	p.drain()
+
 	err := p.cmd.Wait()
 	p.state = p.cmd.ProcessState
 	if err != nil {
@@ -159,6 +203,31 @@ func (p *Proc) Wait() error {
 	return p.close()
 }
 
+// drain waits for stdout and stderr read to the end and the lines delivered to streams within
+// Limits.DeliverTimeout, then the rest are dropped, so a stream nobody reads or an output held open
+// by a grandchild does not block Wait
+func (p *Proc) drain() {
+	done := make(chan struct{})
+	go func() {
+		p.readers.Wait()
+		p.delivering.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-p.ctx.Done():
+	case <-time.After(p.options.Limits.DeliverTimeout):
+	}
+
+	// no more lines are queued, and the queued ones are dropped at once, streams are closed after
+	// nothing is sent to them
+	p.discardMu.Lock()
+	p.discardRest()
+	p.discardMu.Unlock()
+	p.delivering.Wait()
+}
+
 // close process state
 func (p *Proc) close() error {
 	var closeErr error
@@ -177,6 +246,9 @@ func (p *Proc) close() error {
 			for _, stream := range p.stdoutChs {
 				stream.Close()
 			}
+			for _, stream := range p.stdoutLineChs {
+				stream.Close()
+			}
 			p.stdoutPipe.Close()
 		}
 
@@ -184,6 +256,9 @@ func (p *Proc) close() error {
 			for _, stream := range p.stderrChs {
 				stream.Close()
 			}
+			for _, stream := range p.stderrLineChs {
+				stream.Close()
+			}
 			p.stderrPipe.Close()
 		}
 
@@ -332,6 +407,50 @@ func (p *Proc) IOCounters() (*process.IOCountersStat, error) {
 	return p.process.IOCounters()
 }
 
+// NamespaceNetIOCounters returns network IO counters of the network namespace the process runs in, summed over
+// the interfaces except the loopback, so traffic between processes on the same host is not counted. They are
+// not per process: only a process running in a namespace of its own, such as a container, has its own traffic,
+// otherwise the counters are shared by all processes of the namespace, which is the whole host usually.
+// It reads /proc/<pid>/net/dev, and returns errors.ErrUnsupported on platforms other than linux.
+func (p *Proc) NamespaceNetIOCounters() (*net.IOCountersStat, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errors.ErrUnsupported
+	}
+	if p.process == nil {
+		return &net.IOCountersStat{}, nil
+	}
+	return netIOCountersByFile(fmt.Sprintf("/proc/%d/net/dev", p.process.Pid))
+}
+
+// loopbackInterface is the name of the loopback interface on linux
+const loopbackInterface = "lo"
+
+// netIOCountersByFile sums the counters of the interfaces in file of /proc/net/dev format, except the loopback
+func netIOCountersByFile(file string) (*net.IOCountersStat, error) {
+	counters, err := net.IOCountersByFile(true, file)
+	if err != nil {
+		return nil, err
+	}
+
+	total := &net.IOCountersStat{Name: "all"}
+	for _, counter := range counters {
+		if counter.Name == loopbackInterface {
+			continue
+		}
+		total.BytesSent += counter.BytesSent
+		total.BytesRecv += counter.BytesRecv
+		total.PacketsSent += counter.PacketsSent
+		total.PacketsRecv += counter.PacketsRecv
+		total.Errin += counter.Errin
+		total.Errout += counter.Errout
+		total.Dropin += counter.Dropin
+		total.Dropout += counter.Dropout
+		total.Fifoin += counter.Fifoin
+		total.Fifoout += counter.Fifoout
+	}
+	return total, nil
+}
+
 // NumConnections  the number of Connections used by the process.
 // This returns all kind of the connection. This means TCP, UDP or UNIX.
 func (p *Proc) NumConnections() (int, error) {
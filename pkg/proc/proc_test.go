@@ -2,6 +2,8 @@ package proc
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -48,6 +50,10 @@ func testProcess(t *testing.T, proc *Proc) {
 	require.NoError(t, err)
 	t.Logf("io counters: %+v", ioCounters)
 
+	netIOCounters, err := proc.NamespaceNetIOCounters()
+	require.NoError(t, err)
+	t.Logf("net io counters: %+v", netIOCounters)
+
 	running, err := proc.IsRunning()
 	require.NoError(t, err)
 	require.True(t, running)
@@ -178,3 +184,27 @@ done
 
 	t.Log(proc.Wait())
 }
+
+func TestNetIOCountersByFile(t *testing.T) {
+	dev := `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 5000000   40000    0    0    0     0          0         0  5000000   40000    0    0    0     0       0          0
+  eth0:  120000     900    1    2    0     0          0         0    80000     700    0    3    0     0       0          0
+  eth1:    3000      20    0    0    0     0          0         0     1000      10    0    0    0     0       0          0
+`
+	file := filepath.Join(t.TempDir(), "dev")
+	require.NoError(t, os.WriteFile(file, []byte(dev), 0o644))
+
+	counters, err := netIOCountersByFile(file)
+	require.NoError(t, err)
+	t.Logf("net io counters: %+v", counters)
+
+	// the loopback is excluded
+	require.Equal(t, uint64(123000), counters.BytesRecv)
+	require.Equal(t, uint64(81000), counters.BytesSent)
+	require.Equal(t, uint64(920), counters.PacketsRecv)
+	require.Equal(t, uint64(710), counters.PacketsSent)
+	require.Equal(t, uint64(1), counters.Errin)
+	require.Equal(t, uint64(2), counters.Dropin)
+	require.Equal(t, uint64(3), counters.Dropout)
+}
@@ -0,0 +1,103 @@
+package proctest
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+)
+
+// Clock is the source of time, see proc.Clock
+type Clock = proc.Clock
+
+// RealClock is the Clock backed by time package
+type RealClock = proc.RealClock
+
+// NewFakeClock return a fake clock starts at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now, changed: make(chan struct{})}
+}
+
+// FakeClock is a Clock that only moves forward by Advance
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	changed chan struct{}
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After returns a channel receives the time once the clock advanced by d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &fakeTimer{at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.ch <- c.now
+		return timer.ch
+	}
+
+	c.timers = append(c.timers, timer)
+	c.notify()
+	return timer.ch
+}
+
+// Advance moves the clock forward by d, fires the timers due in time order
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	slices.SortStableFunc(c.timers, func(a, b *fakeTimer) int {
+		return a.at.Compare(b.at)
+	})
+	for len(c.timers) > 0 && !c.timers[0].at.After(c.now) {
+		c.timers[0].ch <- c.timers[0].at
+		c.timers = c.timers[1:]
+	}
+	c.notify()
+}
+
+// Timers returns the number of pending timers
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// BlockUntil blocks until there are at least n pending timers,
+// it is used to make sure the code under test is waiting on the clock before Advance.
+func (c *FakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		if len(c.timers) >= n {
+			c.mu.Unlock()
+			return
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		<-changed
+	}
+}
+
+// notify wakes up BlockUntil, must be called with lock held
+func (c *FakeClock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
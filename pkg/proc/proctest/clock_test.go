@@ -0,0 +1,45 @@
+package proctest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	late := clock.After(2 * time.Minute)
+	early := clock.After(time.Minute)
+	require.Equal(t, 2, clock.Timers())
+
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("timer with zero duration should fire immediately")
+	}
+
+	clock.Advance(90 * time.Second)
+	require.Equal(t, start.Add(time.Minute), <-early)
+	require.Equal(t, 1, clock.Timers())
+
+	select {
+	case <-late:
+		t.Fatal("timer should not fire before due")
+	default:
+	}
+
+	done := make(chan struct{})
+	go func() {
+		clock.BlockUntil(2)
+		close(done)
+	}()
+	clock.After(time.Hour)
+	<-done
+
+	clock.Advance(time.Minute)
+	require.Equal(t, start.Add(2*time.Minute), <-late)
+	require.Equal(t, start.Add(150*time.Second), clock.Now())
+}
@@ -0,0 +1,23 @@
+package proctest
+
+type Options struct {
+	// clock used by Sleep steps, use RealClock if nil
+	Clock Clock
+	// error returned by Start
+	StartErr error
+}
+
+// Option apply option into *Options
+type Option func(*Options)
+
+func WithClock(clock Clock) Option {
+	return func(opt *Options) {
+		opt.Clock = clock
+	}
+}
+
+func WithStartError(err error) Option {
+	return func(opt *Options) {
+		opt.StartErr = err
+	}
+}
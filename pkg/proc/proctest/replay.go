@@ -0,0 +1,88 @@
+package proctest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// logTimestamp matches the elapsed time prefix of server log lines, like [00:01:23]:
+var logTimestamp = regexp.MustCompile(`^\[(\d+):(\d{2}):(\d{2})\]: `)
+
+// ReplayLog converts a recorded server log into a script writes each line to stdout then exits with 0,
+// gaps between line timestamps are replayed as Sleep divided by speed, and 0 speed replays without delay.
+// Lines without timestamp, such as lua stack traces, are written right after the previous line.
+func ReplayLog(reader io.Reader, speed float64) ([]Step, error) {
+	var (
+		script []Step
+		last   time.Duration
+	)
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := logTimestamp.FindStringSubmatch(line); match != nil {
+			hours, _ := strconv.Atoi(match[1])
+			minutes, _ := strconv.Atoi(match[2])
+			seconds, _ := strconv.Atoi(match[3])
+			elapsed := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+
+			if elapsed > last && speed > 0 {
+				script = append(script, Sleep(time.Duration(float64(elapsed-last)/speed)))
+			}
+			last = max(last, elapsed)
+		}
+
+		script = append(script, Stdout(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return append(script, Exit(0)), nil
+}
+
+// ReplayFile converts the recorded log file into a script, see ReplayLog
+func ReplayFile(file string, speed float64) ([]Step, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	script, err := ReplayLog(fd, speed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return script, nil
+}
+
+// RunCorpus runs fn in a subtest named by the file for each *.txt log file in dir,
+// with a runner replays the log at speed, options are applied to every runner.
+func RunCorpus(t *testing.T, dir string, speed float64, fn func(t *testing.T, runner *Runner), options ...Option) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no log files found in %s", dir)
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			script, err := ReplayFile(file, speed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fn(t, NewRunner(script, options...))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package proctest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayLog(t *testing.T) {
+	log := `[00:00:00]: Starting Up
+[00:00:10]: Sim paused
+LUA ERROR stack traceback:
+[00:00:05]: out of order
+[00:00:20]: Shutting down`
+
+	script, err := ReplayLog(strings.NewReader(log), 2)
+	require.NoError(t, err)
+	require.Equal(t, []Step{
+		Stdout("[00:00:00]: Starting Up"),
+		Sleep(5 * time.Second),
+		Stdout("[00:00:10]: Sim paused"),
+		Stdout("LUA ERROR stack traceback:"),
+		Stdout("[00:00:05]: out of order"),
+		Sleep(5 * time.Second),
+		Stdout("[00:00:20]: Shutting down"),
+		Exit(0),
+	}, script)
+
+	script, err = ReplayLog(strings.NewReader(log), 0)
+	require.NoError(t, err)
+	require.Len(t, script, 6)
+}
+
+func TestRunCorpus(t *testing.T) {
+	RunCorpus(t, "testdata/logs", 0, func(t *testing.T, runner *Runner) {
+		stdout := runner.StdoutPipe("out")
+		require.NoError(t, runner.Start())
+
+		var lines []string
+		for {
+			line, ok := stdout.Recv()
+			if !ok {
+				break
+			}
+			lines = append(lines, string(line))
+		}
+		require.NoError(t, runner.Wait())
+
+		require.Contains(t, lines[1], "Starting Up")
+		t.Logf("replayed %d lines", len(lines))
+	})
+}
+
+func TestReplay_FakeClock(t *testing.T) {
+	script, err := ReplayFile("testdata/logs/master_startup.txt", 1)
+	require.NoError(t, err)
+
+	clock := NewFakeClock(time.Now())
+	runner := NewRunner(script, WithClock(clock))
+	stdout := runner.StdoutPipe("out")
+	require.NoError(t, runner.Start())
+
+	// read until the world is ready, advancing the clock whenever the replay sleeps
+	var elapsed time.Duration
+	for {
+		line, ok := stdout.TryRecv()
+		if !ok {
+			if clock.Timers() > 0 {
+				clock.Advance(time.Second)
+				elapsed += time.Second
+			}
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		if strings.HasSuffix(string(line), "Sim paused") {
+			break
+		}
+	}
+	require.Equal(t, 9*time.Second, elapsed)
+	require.NoError(t, runner.Kill())
+}
@@ -0,0 +1,339 @@
+package proctest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+)
+
+type stepKind int
+
+const (
+	stepStdout stepKind = iota
+	stepStderr
+	stepSleep
+	stepExpect
+	stepExit
+)
+
+// Step is an action of the scripted process
+type Step struct {
+	kind stepKind
+	text string
+	wait time.Duration
+	code int
+}
+
+// Stdout writes line to stdout
+func Stdout(line string) Step {
+	return Step{kind: stepStdout, text: line}
+}
+
+// Stderr writes line to stderr
+func Stderr(line string) Step {
+	return Step{kind: stepStderr, text: line}
+}
+
+// Sleep waits d on the clock of runner
+func Sleep(d time.Duration) Step {
+	return Step{kind: stepSleep, wait: d}
+}
+
+// Expect waits until a line received from stdin contains text
+func Expect(text string) Step {
+	return Step{kind: stepExpect, text: text}
+}
+
+// Exit exits with code, the rest steps are ignored
+func Exit(code int) Step {
+	return Step{kind: stepExit, code: code}
+}
+
+var pidSeq atomic.Int64
+
+// NewRunner return a fake process runs the script after Start, it keeps running
+// after the last step until it is signaled if there is no Exit step.
+func NewRunner(script []Step, options ...Option) *Runner {
+	var opts Options
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.Clock == nil {
+		opts.Clock = RealClock{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		script:    script,
+		options:   opts,
+		ctx:       ctx,
+		cancel:    cancel,
+		exited:    make(chan struct{}),
+		exitCode:  -1,
+		pid:       -1,
+		inputCh:   make(chan struct{}),
+		stdinChs:  make(map[string]*proc.Stream),
+		stdoutChs: make(map[string]*proc.Stream),
+		stderrChs: make(map[string]*proc.Stream),
+	}
+}
+
+// Runner is a scripted fake process implements proc.Runner
+type Runner struct {
+	script  []Step
+	options Options
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	pid      int
+	exited   chan struct{}
+	exitCode int
+	exitErr  error
+	signal   syscall.Signal
+	signals  []syscall.Signal
+
+	// lines received from stdin
+	inputs  []string
+	inputCh chan struct{}
+
+	stdinChs  map[string]*proc.Stream
+	stdoutChs map[string]*proc.Stream
+	stderrChs map[string]*proc.Stream
+}
+
+var _ proc.Runner = (*Runner)(nil)
+
+func (r *Runner) pipe(chs map[string]*proc.Stream, name string) *proc.Stream {
+	if r.PID() != -1 {
+		panic(fmt.Sprintf("bind pipe after process started: %s", name))
+	}
+
+	ch := proc.MakeChannel[[]byte](0)
+	r.mu.Lock()
+	chs[name] = ch
+	r.mu.Unlock()
+	return ch
+}
+
+// StdinPipe return a named stream pipe with stdin
+func (r *Runner) StdinPipe(name string) *proc.Stream {
+	return r.pipe(r.stdinChs, name)
+}
+
+// StdoutPipe return a named stream pipe with stdout
+func (r *Runner) StdoutPipe(name string) *proc.Stream {
+	return r.pipe(r.stdoutChs, name)
+}
+
+// StderrPipe return a named stream pipe with stderr
+func (r *Runner) StderrPipe(name string) *proc.Stream {
+	return r.pipe(r.stderrChs, name)
+}
+
+// Start starts running the script
+func (r *Runner) Start() error {
+	if r.options.StartErr != nil {
+		return r.options.StartErr
+	}
+
+	r.mu.Lock()
+	if r.pid != -1 {
+		r.mu.Unlock()
+		return errors.New("proctest: already started")
+	}
+	r.pid = int(100000 + pidSeq.Add(1))
+	r.mu.Unlock()
+
+	for _, stdin := range r.stdinChs {
+		go r.readStdin(stdin)
+	}
+
+	go func() {
+		code, err := r.run()
+		r.exit(code, err)
+	}()
+
+	return nil
+}
+
+func (r *Runner) run() (int, error) {
+	for _, step := range r.script {
+		var ok bool
+
+		switch step.kind {
+		case stepStdout:
+			ok = r.send(r.stdoutChs, step.text)
+		case stepStderr:
+			ok = r.send(r.stderrChs, step.text)
+		case stepSleep:
+			select {
+			case <-r.ctx.Done():
+			case <-r.options.Clock.After(step.wait):
+				ok = true
+			}
+		case stepExpect:
+			ok = r.expect(step.text)
+		case stepExit:
+			if step.code != 0 {
+				return step.code, fmt.Errorf("exit status %d", step.code)
+			}
+			return 0, nil
+		}
+
+		if !ok {
+			break
+		}
+	}
+
+	<-r.ctx.Done()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return -1, fmt.Errorf("signal: %s", r.signal)
+}
+
+// send sends line to all streams, returns false if the runner is signaled
+func (r *Runner) send(chs map[string]*proc.Stream, line string) bool {
+	for _, ch := range chs {
+		if !ch.SendContext(r.ctx, []byte(line)) && r.ctx.Err() != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// expect waits for the input contains text, the inputs before the matched one are consumed
+func (r *Runner) expect(text string) bool {
+	for {
+		r.mu.Lock()
+		i := slices.IndexFunc(r.inputs, func(input string) bool { return strings.Contains(input, text) })
+		if i >= 0 {
+			r.inputs = r.inputs[i+1:]
+			r.mu.Unlock()
+			return true
+		}
+		inputCh := r.inputCh
+		r.mu.Unlock()
+
+		select {
+		case <-r.ctx.Done():
+			return false
+		case <-inputCh:
+		}
+	}
+}
+
+func (r *Runner) readStdin(stdin *proc.Stream) {
+	for {
+		bs, ok := stdin.Recv()
+		if !ok {
+			return
+		}
+
+		r.mu.Lock()
+		for _, line := range strings.Split(strings.TrimRight(string(bs), "\n"), "\n") {
+			r.inputs = append(r.inputs, line)
+		}
+		close(r.inputCh)
+		r.inputCh = make(chan struct{})
+		r.mu.Unlock()
+	}
+}
+
+// exit closes streams and marks the runner exited
+func (r *Runner) exit(code int, err error) {
+	r.mu.Lock()
+	r.exitCode = code
+	r.exitErr = err
+	r.cancel()
+	r.mu.Unlock()
+
+	for _, chs := range []map[string]*proc.Stream{r.stdinChs, r.stdoutChs, r.stderrChs} {
+		for _, ch := range chs {
+			ch.Close()
+		}
+	}
+	close(r.exited)
+}
+
+// Wait waits for the script to exit, returns error if exit code is not 0 or it is signaled
+func (r *Runner) Wait() error {
+	if r.PID() == -1 {
+		return errors.New("proctest: not started")
+	}
+
+	<-r.exited
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exitErr
+}
+
+// Signal records the signal, SIGTERM, SIGINT and SIGKILL stop the script
+func (r *Runner) Signal(signal syscall.Signal) error {
+	if r.PID() == -1 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.signals = append(r.signals, signal)
+	switch signal {
+	case syscall.SIGTERM, syscall.SIGINT, syscall.SIGKILL:
+		if r.ctx.Err() == nil {
+			r.signal = signal
+			r.cancel()
+		}
+	}
+	return nil
+}
+
+// Terminate sends syscall.SIGTERM to the runner
+func (r *Runner) Terminate() error {
+	return r.Signal(syscall.SIGTERM)
+}
+
+// Kill sends syscall.SIGKILL to the runner
+func (r *Runner) Kill() error {
+	return r.Signal(syscall.SIGKILL)
+}
+
+// PID returns the fake pid, or -1 if not started
+func (r *Runner) PID() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.pid
+}
+
+// ExitCode returns the exit code, or -1 if the runner hasn't exited or was signaled
+func (r *Runner) ExitCode() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.exitCode
+}
+
+// Signals returns all signals received
+func (r *Runner) Signals() []syscall.Signal {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.signals)
+}
+
+// Exited returns a channel closed after the runner exited
+func (r *Runner) Exited() <-chan struct{} {
+	return r.exited
+}
@@ -0,0 +1,87 @@
+package proctest
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_Script(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	runner := NewRunner([]Step{
+		Stdout("[00:00:01]: Starting Up"),
+		Sleep(10 * time.Second),
+		Stdout("[00:00:11]: Sim paused"),
+		Expect("c_shutdown"),
+		Stderr("[00:00:12]: Shutting down"),
+		Exit(0),
+	}, WithClock(clock))
+
+	stdin := runner.StdinPipe("in")
+	stdout := runner.StdoutPipe("out")
+	stderr := runner.StderrPipe("err")
+
+	require.Equal(t, -1, runner.PID())
+	require.NoError(t, runner.Start())
+	require.Error(t, runner.Start())
+	require.NotEqual(t, -1, runner.PID())
+
+	line, ok := stdout.Recv()
+	require.True(t, ok)
+	require.Equal(t, "[00:00:01]: Starting Up", string(line))
+
+	clock.BlockUntil(1)
+	clock.Advance(10 * time.Second)
+
+	line, ok = stdout.Recv()
+	require.True(t, ok)
+	require.Equal(t, "[00:00:11]: Sim paused", string(line))
+
+	stdin.Send([]byte("c_save()\nc_shutdown()\n"))
+
+	line, ok = stderr.Recv()
+	require.True(t, ok)
+	require.Equal(t, "[00:00:12]: Shutting down", string(line))
+
+	require.NoError(t, runner.Wait())
+	require.Equal(t, 0, runner.ExitCode())
+	require.True(t, stdout.Closed())
+}
+
+func TestRunner_Signal(t *testing.T) {
+	runner := NewRunner([]Step{Stdout("running")})
+	stdout := runner.StdoutPipe("out")
+	require.NoError(t, runner.Start())
+
+	_, ok := stdout.Recv()
+	require.True(t, ok)
+
+	require.NoError(t, runner.Signal(syscall.SIGHUP))
+	require.NoError(t, runner.Terminate())
+	require.EqualError(t, runner.Wait(), "signal: terminated")
+	require.Equal(t, -1, runner.ExitCode())
+	require.Equal(t, []syscall.Signal{syscall.SIGHUP, syscall.SIGTERM}, runner.Signals())
+
+	// a pending write is interrupted by kill
+	runner = NewRunner([]Step{Stdout("nobody reads it"), Exit(0)})
+	runner.StdoutPipe("out")
+	require.NoError(t, runner.Start())
+	require.NoError(t, runner.Kill())
+	<-runner.Exited()
+	require.EqualError(t, runner.Wait(), "signal: killed")
+}
+
+func TestRunner_Exit(t *testing.T) {
+	runner := NewRunner([]Step{Exit(1), Stdout("unreachable")})
+	require.NoError(t, runner.Start())
+	require.EqualError(t, runner.Wait(), "exit status 1")
+	require.Equal(t, 1, runner.ExitCode())
+
+	startErr := errors.New("exec: not found")
+	runner = NewRunner(nil, WithStartError(startErr))
+	require.ErrorIs(t, runner.Start(), startErr)
+	require.Error(t, runner.Wait())
+}
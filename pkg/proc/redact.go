@@ -0,0 +1,52 @@
+package proc
+
+import (
+	"regexp"
+)
+
+// RedactMask replace the secrets matched by redact middlewares
+const RedactMask = "******"
+
+// DefaultRedactPatterns matches secrets commonly printed by the server and steamcmd,
+// the first capture group of each pattern is kept and the rest is masked.
+var DefaultRedactPatterns = []*regexp.Regexp{
+	// cluster token, like pds-g^KU_xxxxxxxx^xxxxxxxx=
+	regexp.MustCompile(`pds-g\^KU_[0-9A-Za-z_-]+\^[0-9A-Za-z+/=]+`),
+	// steamcmd +login <username> <password>
+	regexp.MustCompile(`(\+login\s+\S+\s+)\S+`),
+	// api_key=xxx, token: xxx, password=xxx and so on
+	regexp.MustCompile(`(?i)((?:api[_-]?key|access[_-]?token|token|secret|password)\s*[=:]\s*)\S+`),
+}
+
+// Redact return an OutputMiddleware that masks every match of patterns in a line,
+// uses DefaultRedactPatterns if no pattern is given.
+func Redact(patterns ...*regexp.Regexp) OutputMiddleware {
+	if len(patterns) == 0 {
+		patterns = DefaultRedactPatterns
+	}
+
+	replacement := []byte("${1}" + RedactMask)
+	return func(line []byte) []byte {
+		for _, pattern := range patterns {
+			line = pattern.ReplaceAll(line, replacement)
+		}
+		return line
+	}
+}
+
+// RedactValues return an OutputMiddleware that masks the given literal secrets,
+// such as a known cluster token or steam password.
+func RedactValues(values ...string) OutputMiddleware {
+	var patterns []*regexp.Regexp
+	for _, value := range values {
+		if len(value) == 0 {
+			continue
+		}
+		patterns = append(patterns, regexp.MustCompile(regexp.QuoteMeta(value)))
+	}
+
+	if len(patterns) == 0 {
+		return func(line []byte) []byte { return line }
+	}
+	return Redact(patterns...)
+}
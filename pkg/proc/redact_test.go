@@ -0,0 +1,74 @@
+package proc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	samples := []struct {
+		line   string
+		expect string
+	}{
+		{
+			line:   "cluster token pds-g^KU_abc123XY^Zm9vYmFyCg==",
+			expect: "cluster token " + RedactMask,
+		},
+		{
+			line:   "steamcmd +login steamuser hunter2 +quit",
+			expect: "steamcmd +login steamuser " + RedactMask + " +quit",
+		},
+		{
+			line:   "API_KEY=abcdef password: 123456",
+			expect: "API_KEY=" + RedactMask + " password: " + RedactMask,
+		},
+		{
+			line:   "[00:00:01]: Sim paused",
+			expect: "[00:00:01]: Sim paused",
+		},
+	}
+
+	redact := Redact()
+	for _, s := range samples {
+		require.Equal(t, s.expect, string(redact([]byte(s.line))))
+	}
+
+	redactValues := RedactValues("s3cr.t", "")
+	require.Equal(t, "value "+RedactMask+" s3cret", string(redactValues([]byte("value s3cr.t s3cret"))))
+}
+
+func TestProc_RedactStdout(t *testing.T) {
+	ctx := context.Background()
+	proc, err := NewProc(
+		ctx,
+		WithCommand("echo", "token=pds-g^KU_abc123XY^Zm9vYmFyCg=="),
+		WithStdout(),
+		WithOutputMiddleware(Redact()),
+	)
+	require.NoError(t, err)
+
+	pipe := proc.StdoutPipe("echo")
+
+	var lines []string
+	done := make(chan struct{})
+	go func() {
+		for !pipe.Closed() {
+			recv, ok := pipe.Recv()
+			if ok {
+				t.Log(string(recv))
+				lines = append(lines, string(recv))
+			}
+		}
+		close(done)
+	}()
+
+	require.NoError(t, proc.Start())
+	time.Sleep(time.Second)
+	t.Log(proc.Wait())
+	<-done
+
+	require.Equal(t, []string{"token=" + RedactMask}, lines)
+}
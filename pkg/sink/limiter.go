@@ -0,0 +1,38 @@
+package sink
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+)
+
+func newLimiter(rate float64, burst int, clock proc.Clock) *limiter {
+	return &limiter{clock: clock, rate: rate, burst: float64(burst), tokens: float64(burst), last: clock.Now()}
+}
+
+// limiter is a token bucket refilled at rate tokens per second on clock
+type limiter struct {
+	mu     sync.Mutex
+	clock  proc.Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// Allow reports whether a token is available and takes it
+func (l *limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,29 @@
+package sink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc/proctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	clock := proctest.NewFakeClock(time.Now())
+	limiter := newLimiter(2, 2, clock)
+
+	require.True(t, limiter.Allow())
+	require.True(t, limiter.Allow())
+	require.False(t, limiter.Allow())
+
+	// refilled at 2 tokens per second
+	clock.Advance(500 * time.Millisecond)
+	require.True(t, limiter.Allow())
+	require.False(t, limiter.Allow())
+
+	// no more than burst
+	clock.Advance(time.Hour)
+	require.True(t, limiter.Allow())
+	require.True(t, limiter.Allow())
+	require.False(t, limiter.Allow())
+}
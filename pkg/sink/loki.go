@@ -0,0 +1,133 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type LokiOptions struct {
+	// base url of loki, such as http://localhost:3100
+	URL string
+	// X-Scope-OrgID header for multi-tenant loki
+	TenantID string
+	// basic auth, ignored if username is empty
+	Username string
+	Password string
+
+	// http client, use http.DefaultClient if nil
+	Client *http.Client
+}
+
+// NewLoki return a sink which pushes records to loki, records are grouped into streams by their labels
+func NewLoki(opts LokiOptions) *Loki {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return &Loki{options: opts}
+}
+
+// Loki is a sink pushes records by loki http api
+type Loki struct {
+	options LokiOptions
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// Write pushes records into loki, it returns *PermanentError if loki rejects records with a 4xx status
+// other than 429, such as a bad payload or too large entries, which retrying can not fix
+func (l *Loki) Write(ctx context.Context, records []Record) error {
+	body, err := json.Marshal(l.push(records))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(l.options.URL, "/")+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(l.options.TenantID) > 0 {
+		req.Header.Set("X-Scope-OrgID", l.options.TenantID)
+	}
+	if len(l.options.Username) > 0 {
+		req.SetBasicAuth(l.options.Username, l.options.Password)
+	}
+
+	resp, err := l.options.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("loki push: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return &PermanentError{Err: err}
+	}
+	return err
+}
+
+// push groups records into streams by labels
+func (l *Loki) push(records []Record) lokiPush {
+	var push lokiPush
+	streams := make(map[string]int)
+
+	for _, record := range records {
+		labels := make(map[string]string, len(record.Labels))
+		var keys []string
+		for name, value := range record.Labels {
+			name = lokiLabelName(name)
+			labels[name] = value
+			keys = append(keys, name+"="+strconv.Quote(value))
+		}
+		slices.Sort(keys)
+		key := strings.Join(keys, ",")
+
+		i, ok := streams[key]
+		if !ok {
+			i = len(push.Streams)
+			streams[key] = i
+			push.Streams = append(push.Streams, lokiStream{Stream: labels})
+		}
+		push.Streams[i].Values = append(push.Streams[i].Values, [2]string{
+			strconv.FormatInt(record.Time.UnixNano(), 10),
+			record.Line,
+		})
+	}
+	return push
+}
+
+// Close does nothing, loki sink has no connection to close
+func (l *Loki) Close() error {
+	return nil
+}
+
+// lokiLabelName replaces the characters not allowed in label name with underscore
+func lokiLabelName(name string) string {
+	runes := []rune(name)
+	for i, r := range runes {
+		valid := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')
+		if !valid {
+			runes[i] = '_'
+		}
+	}
+	return string(runes)
+}
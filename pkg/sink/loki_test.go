@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoki_Retryable(t *testing.T) {
+	for status, retryable := range map[int]bool{
+		http.StatusBadRequest:            false,
+		http.StatusRequestEntityTooLarge: false,
+		http.StatusTooManyRequests:       true,
+		http.StatusInternalServerError:   true,
+		http.StatusBadGateway:            true,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(status), status)
+		}))
+
+		err := NewLoki(LokiOptions{URL: server.URL}).Write(context.Background(), []Record{{Line: "line"}})
+		server.Close()
+		t.Log(err)
+
+		var permanent *PermanentError
+		require.Error(t, err)
+		require.Equal(t, !retryable, errors.As(err, &permanent), status)
+	}
+
+	// network errors are retried
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	err := NewLoki(LokiOptions{URL: server.URL}).Write(context.Background(), []Record{{Line: "line"}})
+	var permanent *PermanentError
+	require.Error(t, err)
+	require.False(t, errors.As(err, &permanent))
+}
+
+func TestLoki_Write(t *testing.T) {
+	var (
+		push   lokiPush
+		tenant string
+		calls  int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "ingester unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		require.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		tenant = r.Header.Get("X-Scope-OrgID")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&push))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	loki := NewLoki(LokiOptions{URL: server.URL + "/", TenantID: "dst"})
+	defer loki.Close()
+
+	ts := time.Unix(1733040000, 0)
+	records := []Record{
+		{Time: ts, Labels: map[string]string{"shard": "Master", "stream": "stdout"}, Line: "line 1"},
+		{Time: ts.Add(time.Second), Labels: map[string]string{"shard": "Caves", "stream": "stdout"}, Line: "line 2"},
+		{Time: ts.Add(2 * time.Second), Labels: map[string]string{"shard": "Master", "stream": "stdout"}, Line: "line 3"},
+		{Time: ts.Add(3 * time.Second), Labels: map[string]string{"9-shard.id": "1"}, Line: "line 4"},
+	}
+
+	err := loki.Write(context.Background(), records)
+	require.ErrorContains(t, err, "503")
+	var permanent *PermanentError
+	require.False(t, errors.As(err, &permanent))
+
+	require.NoError(t, loki.Write(context.Background(), records))
+	require.Equal(t, "dst", tenant)
+	require.Equal(t, lokiPush{Streams: []lokiStream{
+		{
+			Stream: map[string]string{"shard": "Master", "stream": "stdout"},
+			Values: [][2]string{{"1733040000000000000", "line 1"}, {"1733040002000000000", "line 3"}},
+		},
+		{
+			Stream: map[string]string{"shard": "Caves", "stream": "stdout"},
+			Values: [][2]string{{"1733040001000000000", "line 2"}},
+		},
+		{
+			Stream: map[string]string{"__shard_id": "1"},
+			Values: [][2]string{{"1733040003000000000", "line 4"}},
+		},
+	}}, push)
+}
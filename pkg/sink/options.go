@@ -0,0 +1,70 @@
+package sink
+
+import (
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+)
+
+type Options struct {
+	// max records in a batch
+	BatchSize int
+	// max wait time before a batch is written
+	FlushInterval time.Duration
+
+	// max lines per second, the excess lines are dropped, no limit if zero
+	RateLimit float64
+	// max lines allowed at once
+	Burst int
+
+	// retries of a failed batch, a negative one is taken as zero
+	Retries int
+	// backoff before next retry, grows linearly with retries
+	RetryBackoff time.Duration
+
+	// labels added into every record
+	Labels map[string]string
+
+	// source of time of records, flushes and retries, default proc.RealClock
+	Clock proc.Clock
+}
+
+// Option apply option into *Options
+type Option func(*Options)
+
+// WithBatch sets the max records of a batch and the max wait before it is written,
+// a non-positive interval keeps the default of a second
+func WithBatch(size int, interval time.Duration) Option {
+	return func(opt *Options) {
+		opt.BatchSize = max(size, 1)
+		if interval > 0 {
+			opt.FlushInterval = interval
+		}
+	}
+}
+
+func WithRateLimit(linesPerSecond float64, burst int) Option {
+	return func(opt *Options) {
+		opt.RateLimit = linesPerSecond
+		opt.Burst = burst
+	}
+}
+
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(opt *Options) {
+		opt.Retries = retries
+		opt.RetryBackoff = backoff
+	}
+}
+
+func WithLabels(labels map[string]string) Option {
+	return func(opt *Options) {
+		opt.Labels = labels
+	}
+}
+
+func WithClock(clock proc.Clock) Option {
+	return func(opt *Options) {
+		opt.Clock = clock
+	}
+}
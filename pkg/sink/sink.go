@@ -0,0 +1,223 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync/atomic"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+)
+
+// Record is a log line read from a process stream
+type Record struct {
+	Time time.Time
+	// labels of the stream, such as cluster, shard and stream
+	Labels map[string]string
+	Line   string
+	// the line was truncated by the process stream, see proc.Limits
+	Truncated bool
+}
+
+// Sink writes records into somewhere outside the process
+type Sink interface {
+	// Write writes a batch of records, it should be safe to retry on error, or return *PartialWriteError
+	// if some records are written already, so only the rest are retried, or *PermanentError if retrying
+	// can not succeed, such as the records are rejected
+	Write(ctx context.Context, records []Record) error
+	Close() error
+}
+
+// PartialWriteError is returned by Sink.Write if it failed after the first Written records were written
+type PartialWriteError struct {
+	Written int
+	Err     error
+}
+
+func (e *PartialWriteError) Error() string {
+	return fmt.Sprintf("%d records written: %s", e.Written, e.Err)
+}
+
+func (e *PartialWriteError) Unwrap() error {
+	return e.Err
+}
+
+// PermanentError is returned by Sink.Write if the records can never be written, the batch is not retried
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// NewForwarder return a forwarder which forwards stream lines into sink
+func NewForwarder(sink Sink, options ...Option) *Forwarder {
+	opts := Options{
+		BatchSize:     100,
+		FlushInterval: time.Second,
+		Retries:       3,
+		RetryBackoff:  time.Second,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	opts.Retries = max(opts.Retries, 0)
+	if opts.Clock == nil {
+		opts.Clock = proc.RealClock{}
+	}
+
+	forwarder := &Forwarder{sink: sink, options: opts}
+	if opts.RateLimit > 0 {
+		forwarder.limiter = newLimiter(opts.RateLimit, max(opts.Burst, 1), opts.Clock)
+	}
+	return forwarder
+}
+
+// Forwarder reads lines from streams, and writes them into sink in batches
+type Forwarder struct {
+	sink    Sink
+	limiter *limiter
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+
+	options Options
+}
+
+// Dropped returns the number of lines dropped by rate limit
+func (f *Forwarder) Dropped() uint64 {
+	return f.dropped.Load()
+}
+
+// Failed returns the number of records dropped because their batch still failed after retries
+func (f *Forwarder) Failed() uint64 {
+	return f.failed.Load()
+}
+
+// shutdownFlushTimeout bounds writing the pending batch after ctx of Forward is done
+const shutdownFlushTimeout = 5 * time.Second
+
+// Forward forwards lines from stream with labels until the stream closed or ctx done, the pending batch is
+// flushed before it returns. A batch still failing after retries is dropped and counted by Failed, and
+// forwarding goes on, the error of the last failed batch is returned.
+func (f *Forwarder) Forward(ctx context.Context, stream *proc.LineStream, labels map[string]string) error {
+	// labels of stream take precedence over the forwarder's
+	merged := make(map[string]string, len(f.options.Labels)+len(labels))
+	maps.Copy(merged, f.options.Labels)
+	maps.Copy(merged, labels)
+	labels = merged
+
+	lines := make(chan Record)
+	receiving := make(chan struct{})
+	// the stream is not closed by Forward, receiving stops once ctx is done, so nothing is left reading it
+	defer func() { <-receiving }()
+	go func() {
+		defer close(receiving)
+		defer close(lines)
+		for {
+			line, ok := stream.RecvContext(ctx)
+			if !ok {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case lines <- Record{Time: f.options.Clock.Now(), Labels: labels, Line: string(line.Data), Truncated: line.Truncated}:
+			}
+		}
+	}()
+
+	clock := f.options.Clock
+	flushAfter := clock.After(f.options.FlushInterval)
+
+	var (
+		batch   []Record
+		dropped uint64
+		lastErr error
+	)
+	flush := func(ctx context.Context) {
+		if dropped > 0 {
+			batch = append(batch, Record{
+				Time:   clock.Now(),
+				Labels: labels,
+				Line:   fmt.Sprintf("%d lines dropped by rate limit", dropped),
+			})
+			dropped = 0
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if failed, err := f.write(ctx, batch); err != nil {
+			f.failed.Add(uint64(failed))
+			lastErr = err
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is done already, write the pending batch with a short one
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownFlushTimeout)
+			flush(flushCtx)
+			cancel()
+			return lastErr
+		case <-flushAfter:
+			flush(ctx)
+			flushAfter = clock.After(f.options.FlushInterval)
+		case record, ok := <-lines:
+			if !ok {
+				flush(ctx)
+				return lastErr
+			}
+
+			if f.limiter != nil && !f.limiter.Allow() {
+				dropped++
+				f.dropped.Add(1)
+				continue
+			}
+
+			batch = append(batch, record)
+			if len(batch) >= f.options.BatchSize {
+				flush(ctx)
+			}
+		}
+	}
+}
+
+// write writes batch into sink, retries the records not written yet with backoff on error,
+// it returns the number of records still not written
+func (f *Forwarder) write(ctx context.Context, batch []Record) (int, error) {
+	var err error
+	for i := 0; i <= f.options.Retries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return len(batch), err
+			case <-f.options.Clock.After(f.options.RetryBackoff * time.Duration(i)):
+			}
+		}
+
+		if err = f.sink.Write(ctx, batch); err == nil {
+			return 0, nil
+		}
+		var permanent *PermanentError
+		if errors.As(err, &permanent) {
+			return len(batch), err
+		}
+		var partial *PartialWriteError
+		if errors.As(err, &partial) {
+			batch = batch[min(max(partial.Written, 0), len(batch)):]
+		}
+	}
+	return len(batch), err
+}
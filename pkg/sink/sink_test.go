@@ -0,0 +1,287 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dstgo/dontstarve/pkg/proc"
+	"github.com/dstgo/dontstarve/pkg/proc/proctest"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySink keeps records in memory, fails the first n writes
+type memorySink struct {
+	mu      sync.Mutex
+	records []Record
+	fails   int
+}
+
+func (m *memorySink) Write(ctx context.Context, records []Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.fails > 0 {
+		m.fails--
+		return errors.New("write failed")
+	}
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func (m *memorySink) Close() error {
+	return nil
+}
+
+func (m *memorySink) Lines() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var lines []string
+	for _, record := range m.records {
+		lines = append(lines, record.Line)
+	}
+	return lines
+}
+
+func forwardProcess(t *testing.T, forwarder *Forwarder, script string) error {
+	p, err := proc.NewProc(
+		context.Background(),
+		proc.WithCommand("bash", "-c", script),
+		proc.WithStdout(),
+	)
+	require.NoError(t, err)
+	stdout := p.StdoutLines("sink")
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.Forward(context.Background(), stdout, map[string]string{"stream": "stdout"})
+	}()
+
+	require.NoError(t, p.Start())
+	t.Log(p.Wait())
+
+	return <-errCh
+}
+
+func TestForwarder_Forward(t *testing.T) {
+	sink := &memorySink{fails: 1}
+	forwarder := NewForwarder(sink,
+		WithBatch(2, 100*time.Millisecond),
+		WithRetry(1, 10*time.Millisecond),
+		WithLabels(map[string]string{"cluster": "Cluster_1", "stream": "unknown"}),
+	)
+
+	err := forwardProcess(t, forwarder, `for i in 1 2 3; do echo "line $i"; done`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"line 1", "line 2", "line 3"}, sink.Lines())
+	require.Equal(t, map[string]string{"cluster": "Cluster_1", "stream": "stdout"}, sink.records[0].Labels)
+}
+
+func TestForwarder_RateLimit(t *testing.T) {
+	sink := &memorySink{}
+	// the clock does not move, so no token is refilled
+	forwarder := NewForwarder(sink, WithRateLimit(1, 2), WithClock(proctest.NewFakeClock(time.Now())))
+
+	err := forwardProcess(t, forwarder, `for i in 1 2 3 4 5; do echo "line $i"; done`)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), forwarder.Dropped())
+	require.Equal(t, []string{"line 1", "line 2", "3 lines dropped by rate limit"}, sink.Lines())
+}
+
+func TestForwarder_RetryExhausted(t *testing.T) {
+	sink := &memorySink{fails: 2}
+	forwarder := NewForwarder(sink, WithBatch(1, time.Second), WithRetry(1, 10*time.Millisecond))
+
+	// the first batch fails after retries, it is dropped and the rest are forwarded
+	err := forwardProcess(t, forwarder, `for i in 1 2 3; do echo "line $i"; done`)
+	require.Error(t, err)
+	require.Equal(t, uint64(1), forwarder.Failed())
+	require.Equal(t, []string{"line 2", "line 3"}, sink.Lines())
+}
+
+// partialSink writes the first record of each batch only, until it fails fails times
+type partialSink struct {
+	memorySink
+	fails int
+}
+
+func (p *partialSink) Write(ctx context.Context, records []Record) error {
+	if p.fails > 0 && len(records) > 1 {
+		p.fails--
+		p.memorySink.Write(ctx, records[:1])
+		return &PartialWriteError{Written: 1, Err: errors.New("connection reset")}
+	}
+	return p.memorySink.Write(ctx, records)
+}
+
+func TestForwarder_PartialWrite(t *testing.T) {
+	sink := &partialSink{fails: 2}
+	forwarder := NewForwarder(sink, WithRetry(2, time.Millisecond))
+
+	// all lines go in one batch written when the stream is closed
+	stream := proc.MakeChannel[proc.Line](0)
+	go func() {
+		for i := 1; i <= 4; i++ {
+			stream.Send(proc.Line{Data: []byte(fmt.Sprintf("line %d", i))})
+		}
+		stream.Close()
+	}()
+
+	require.NoError(t, forwarder.Forward(context.Background(), stream, nil))
+	// the records written are not sent again
+	require.Equal(t, []string{"line 1", "line 2", "line 3", "line 4"}, sink.Lines())
+	require.Zero(t, forwarder.Failed())
+}
+
+// rejectingSink rejects every batch permanently
+type rejectingSink struct {
+	memorySink
+	writes int
+}
+
+func (r *rejectingSink) Write(ctx context.Context, records []Record) error {
+	r.writes++
+	return &PermanentError{Err: errors.New("entry too large")}
+}
+
+func TestForwarder_PermanentError(t *testing.T) {
+	sink := &rejectingSink{}
+	forwarder := NewForwarder(sink, WithRetry(3, time.Hour))
+
+	err := forwardProcess(t, forwarder, `echo hello`)
+	require.ErrorContains(t, err, "entry too large")
+	// no retry, so no backoff either
+	require.Equal(t, 1, sink.writes)
+	require.Equal(t, uint64(1), forwarder.Failed())
+}
+
+func TestForwarder_NegativeRetries(t *testing.T) {
+	sink := &memorySink{fails: 1}
+	forwarder := NewForwarder(sink, WithRetry(-1, time.Millisecond))
+
+	err := forwardProcess(t, forwarder, `echo hello`)
+	require.Error(t, err)
+	require.Equal(t, uint64(1), forwarder.Failed())
+}
+
+func TestForwarder_StopReceiving(t *testing.T) {
+	forwarder := NewForwarder(&memorySink{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := proc.MakeChannel[proc.Line](0)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.Forward(ctx, stream, nil)
+	}()
+
+	stream.Send(proc.Line{Data: []byte("line 1")})
+	cancel()
+	require.NoError(t, <-errCh)
+
+	// nothing is left receiving from the stream
+	require.False(t, stream.TrySend(proc.Line{Data: []byte("line 2")}))
+}
+
+func TestForwarder_ZeroInterval(t *testing.T) {
+	sink := &memorySink{}
+	forwarder := NewForwarder(sink, WithBatch(2, 0))
+	require.Equal(t, time.Second, forwarder.options.FlushInterval)
+
+	err := forwardProcess(t, forwarder, `echo hello`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hello"}, sink.Lines())
+}
+
+func TestForwarder_FlushOnDone(t *testing.T) {
+	sink := &memorySink{}
+	forwarder := NewForwarder(sink, WithBatch(100, time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := proc.MakeChannel[proc.Line](0)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.Forward(ctx, stream, nil)
+	}()
+
+	// the second line is received after the first one is in the batch
+	stream.Send(proc.Line{Data: []byte("line 1")})
+	stream.Send(proc.Line{Data: []byte("line 2")})
+	cancel()
+
+	require.NoError(t, <-errCh)
+	require.Contains(t, sink.Lines(), "line 1")
+}
+
+func TestForwarder_Truncated(t *testing.T) {
+	sink := &memorySink{}
+	forwarder := NewForwarder(sink)
+
+	stream := proc.MakeChannel[proc.Line](0)
+	go func() {
+		stream.Send(proc.Line{Data: []byte("short")})
+		stream.Send(proc.Line{Data: []byte("long"), Truncated: true})
+		stream.Close()
+	}()
+
+	require.NoError(t, forwarder.Forward(context.Background(), stream, nil))
+	require.Len(t, sink.records, 2)
+	require.False(t, sink.records[0].Truncated)
+	require.True(t, sink.records[1].Truncated)
+}
+
+func TestForwarder_FlushInterval(t *testing.T) {
+	sink := &memorySink{}
+	clock := proctest.NewFakeClock(time.Now())
+	forwarder := NewForwarder(sink, WithBatch(100, time.Minute), WithClock(clock))
+
+	stream := proc.MakeChannel[proc.Line](0)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.Forward(context.Background(), stream, nil)
+	}()
+
+	clock.BlockUntil(1)
+	// the second line is received after the first one is in the batch
+	stream.Send(proc.Line{Data: []byte("line 1")})
+	stream.Send(proc.Line{Data: []byte("line 2")})
+	require.Empty(t, sink.Lines())
+
+	// the batch is written once the interval passed, and the next flush is scheduled
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	require.Contains(t, sink.Lines(), "line 1")
+
+	stream.Close()
+	require.NoError(t, <-errCh)
+	require.Equal(t, []string{"line 1", "line 2"}, sink.Lines())
+}
+
+func TestForwarder_RetryBackoff(t *testing.T) {
+	sink := &memorySink{fails: 1}
+	clock := proctest.NewFakeClock(time.Now())
+	forwarder := NewForwarder(sink, WithRetry(1, time.Hour), WithClock(clock))
+
+	stream := proc.MakeChannel[proc.Line](0)
+	go func() {
+		stream.Send(proc.Line{Data: []byte("hello")})
+		stream.Close()
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.Forward(context.Background(), stream, nil)
+	}()
+
+	// the flush timer and the backoff
+	clock.BlockUntil(2)
+	require.Empty(t, sink.Lines())
+	clock.Advance(time.Hour)
+
+	require.NoError(t, <-errCh)
+	require.Equal(t, []string{"hello"}, sink.Lines())
+	require.Zero(t, forwarder.Failed())
+}
@@ -0,0 +1,181 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// syslog facility and severity, see RFC5424 section 6.2.1
+const (
+	FacilityLocal0 = 16
+
+	SeverityError = 3
+	SeverityInfo  = 6
+)
+
+// sdID is the structured data id of record labels, 32473 is the enterprise number reserved for documentation
+const sdID = "labels@32473"
+
+type SyslogOptions struct {
+	// address of the syslog server, host:port
+	Address string
+	// use tls if not nil
+	TLSConfig *tls.Config
+
+	Facility int
+	AppName  string
+	Hostname string
+}
+
+// NewSyslog return a sink which writes records to a syslog server over tcp or tls,
+// messages are formatted by RFC5424 and framed with octet counting by RFC6587.
+func NewSyslog(opts SyslogOptions) *Syslog {
+	if opts.Facility == 0 {
+		opts.Facility = FacilityLocal0
+	}
+	if len(opts.AppName) == 0 {
+		opts.AppName = "dontstarve"
+	}
+	if len(opts.Hostname) == 0 {
+		opts.Hostname, _ = os.Hostname()
+	}
+	return &Syslog{options: opts}
+}
+
+// Syslog is a sink writes records into remote syslog server
+type Syslog struct {
+	mu   sync.Mutex
+	conn net.Conn
+
+	options SyslogOptions
+}
+
+// Write writes records into syslog server, connection is re-established on the next write if failed.
+// It returns *PartialWriteError if some records were written before it failed, a record written partly
+// is not counted, its frame is cut by closing the connection so the server drops it.
+func (s *Syslog) Write(ctx context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetWriteDeadline(deadline)
+	}
+
+	var (
+		buf bytes.Buffer
+		// end offset of each frame in buf
+		ends = make([]int, 0, len(records))
+	)
+	for _, record := range records {
+		msg := s.format(record)
+		fmt.Fprintf(&buf, "%d %s", len(msg), msg)
+		ends = append(ends, buf.Len())
+	}
+
+	n, err := s.conn.Write(buf.Bytes())
+	if err != nil {
+		written, _ := slices.BinarySearch(ends, n+1)
+		if written > 0 {
+			return &PartialWriteError{Written: written, Err: s.reset(err)}
+		}
+		return s.reset(err)
+	}
+	return nil
+}
+
+func (s *Syslog) dial(ctx context.Context) (net.Conn, error) {
+	if s.options.TLSConfig != nil {
+		dialer := &tls.Dialer{Config: s.options.TLSConfig}
+		return dialer.DialContext(ctx, "tcp", s.options.Address)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", s.options.Address)
+}
+
+// reset closes the broken connection
+func (s *Syslog) reset(err error) error {
+	_ = s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format formats record as RFC5424 message
+func (s *Syslog) format(record Record) string {
+	severity := SeverityInfo
+	if record.Labels["stream"] == "stderr" {
+		severity = SeverityError
+	}
+
+	msgID := "-"
+	if stream := record.Labels["stream"]; len(stream) > 0 {
+		msgID = stream
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s - %s %s %s",
+		s.options.Facility*8+severity,
+		record.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		nilValue(s.options.Hostname),
+		nilValue(s.options.AppName),
+		msgID,
+		structuredData(record.Labels),
+		record.Line,
+	)
+}
+
+// Close closes the connection
+func (s *Syslog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// structuredData formats labels as a structured data element
+func structuredData(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "-"
+	}
+
+	var names []string
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var builder strings.Builder
+	builder.WriteString("[" + sdID)
+	for _, name := range names {
+		builder.WriteString(fmt.Sprintf(` %s="%s"`, name, sdEscaper.Replace(labels[name])))
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
+
+var sdEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+func nilValue(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
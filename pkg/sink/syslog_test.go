@@ -0,0 +1,120 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readFrames reads octet counting frames from conn
+func readFrames(conn net.Conn, n int) ([]string, error) {
+	reader := bufio.NewReader(conn)
+
+	var frames []string
+	for range n {
+		length, err := reader.ReadString(' ')
+		if err != nil {
+			return frames, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			return frames, err
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return frames, err
+		}
+		frames = append(frames, string(frame))
+	}
+	return frames, nil
+}
+
+// brokenConn accepts limit bytes, then fails
+type brokenConn struct {
+	net.Conn
+	written bytes.Buffer
+	limit   int
+}
+
+func (c *brokenConn) Write(b []byte) (int, error) {
+	n := min(len(b), c.limit-c.written.Len())
+	c.written.Write(b[:n])
+	if n < len(b) {
+		return n, errors.New("connection reset by peer")
+	}
+	return n, nil
+}
+
+func (c *brokenConn) Close() error {
+	return nil
+}
+
+func TestSyslog_PartialWrite(t *testing.T) {
+	syslog := NewSyslog(SyslogOptions{Hostname: "host"})
+	records := []Record{{Line: "line 1"}, {Line: "line 2"}, {Line: "line 3"}}
+	first := syslog.format(records[0])
+	frame := strconv.Itoa(len(first)) + " " + first
+
+	// the connection breaks in the middle of the second frame
+	conn := &brokenConn{limit: len(frame) + 5}
+	syslog.conn = conn
+	err := syslog.Write(context.Background(), records)
+	t.Log(err)
+
+	var partial *PartialWriteError
+	require.ErrorAs(t, err, &partial)
+	require.Equal(t, 1, partial.Written)
+	require.Nil(t, syslog.conn)
+
+	// nothing written
+	syslog.conn = &brokenConn{}
+	err = syslog.Write(context.Background(), records)
+	require.Error(t, err)
+	require.False(t, errors.As(err, &partial))
+}
+
+func TestSyslog_Write(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	framesCh := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			framesCh <- nil
+			return
+		}
+		defer conn.Close()
+		frames, _ := readFrames(conn, 2)
+		framesCh <- frames
+	}()
+
+	syslog := NewSyslog(SyslogOptions{Address: listener.Addr().String(), Hostname: "host"})
+	defer syslog.Close()
+
+	ts := time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)
+	err = syslog.Write(context.Background(), []Record{
+		{Time: ts, Labels: map[string]string{"stream": "stdout", "shard": "Master"}, Line: "[00:00:01]: Sim paused"},
+		{Time: ts, Labels: map[string]string{"stream": "stderr", "cluster": `a"b]`}, Line: "error"},
+	})
+	require.NoError(t, err)
+
+	frames := <-framesCh
+	for _, frame := range frames {
+		t.Log(frame)
+	}
+	require.Equal(t, []string{
+		`<134>1 2024-12-01T08:00:00.000000Z host dontstarve - stdout [labels@32473 shard="Master" stream="stdout"] [00:00:01]: Sim paused`,
+		`<131>1 2024-12-01T08:00:00.000000Z host dontstarve - stderr [labels@32473 cluster="a\"b\]" stream="stderr"] error`,
+	}, frames)
+}